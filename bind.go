@@ -0,0 +1,243 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/wiggin77/merror"
+)
+
+const (
+	// TagName is the struct tag containing the property name bound to a field.
+	TagName = "cfg"
+
+	// TagDefault is the struct tag containing the default value for a field
+	// when the property is not found.
+	TagDefault = "default"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Bind populates the fields of the struct pointed to by `v` with property
+// values. Fields are bound using the `cfg` struct tag, which names the property.
+// An optional `default` struct tag provides a value used when the property is
+// not found. Fields for which the property is not found and no default is
+// provided are left unchanged.
+//
+// Nested struct fields with a `cfg` tag map to an INI section, meaning the tag
+// is used as a prefix for the nested fields, e.g. a nested struct tagged `db`
+// containing a field tagged `host` is bound to property `db.host`. Nested
+// structs without a tag are bound using the current prefix.
+//
+// Supported field types are string, bool, signed and unsigned integers,
+// float32, float64 and `time.Duration`, parsed the same way as `String`,
+// `Bool`, `Int64`, `Float64` and `Duration` respectively, plus `time.Time`
+// parsed as RFC 3339. Struct types with no exported fields, other than
+// `time.Time`, are not supported.
+//
+// All fields are processed even if errors occur. Any errors are aggregated
+// and returned as a `merror.MError`.
+//
+// Example:
+//
+//	type DBConfig struct {
+//		Host    string        `cfg:"host" default:"localhost"`
+//		Port    int           `cfg:"port" default:"5432"`
+//		Timeout time.Duration `cfg:"timeout" default:"30 seconds"`
+//	}
+//	type AppConfig struct {
+//		Name string   `cfg:"name"`
+//		DB   DBConfig `cfg:"db"`
+//	}
+func (config *Config) Bind(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bind requires a non-nil pointer to a struct")
+	}
+	merr := merror.New()
+	bindStruct(rv.Elem(), "", config.snapshot(), merr)
+	return merr.ErrorOrNil()
+}
+
+// propSnapshot holds the effective property values at a point in time, so
+// every field of a struct is bound from the same set of values even if the
+// config is reloaded while binding.
+type propSnapshot struct {
+	resolved map[string]string
+	props    map[string]string
+	errs     map[string]error
+	envRefs  map[string]bool
+}
+
+// snapshot returns the current effective property values. The maps are
+// replaced, never modified, by `updateEffective` meaning they can be read
+// after `mutexSrc` is released.
+func (config *Config) snapshot() propSnapshot {
+	config.mutexSrc.RLock()
+	defer config.mutexSrc.RUnlock()
+	return config.snapshotLocked()
+}
+
+// snapshotLocked returns the current effective property values.
+//
+// Must be called with `mutexSrc` locked.
+func (config *Config) snapshotLocked() propSnapshot {
+	return propSnapshot{
+		resolved: config.resolved,
+		props:    config.effective,
+		errs:     config.effectiveErrs,
+		envRefs:  config.envRefs,
+	}
+}
+
+// value returns the effective value of the named property, or an error if
+// the value could not be interpolated. Values referencing environment
+// variables are interpolated again so the current environment is used.
+func (snap propSnapshot) value(name string) (val string, ok bool, err error) {
+	if snap.envRefs[name] {
+		ip := newInterpolator(snap.resolved)
+		if val, ok, err = ip.resolve(name); err != nil {
+			val = snap.resolved[name]
+		}
+		return
+	}
+	val, ok = snap.props[name]
+	err = snap.errs[name]
+	return
+}
+
+// get returns the value of the named property, `ErrNotFound` if the
+// property is not found, or the interpolation error for the property.
+// See `Config.String`.
+func (snap propSnapshot) get(name string) (string, error) {
+	val, ok, err := snap.value(name)
+	if !ok {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return val, nil
+}
+
+// bindStruct binds each field of a struct value from `snap`, prefixing
+// property names with `prefix`.
+func bindStruct(rv reflect.Value, prefix string, snap propSnapshot, merr *merror.MError) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		tag, hasTag := field.Tag.Lookup(TagName)
+		if tag == "-" {
+			continue
+		}
+
+		// Exported fields of embedded structs are settable even
+		// when the embedded type is not exported.
+		if isNested(field.Type) && (fv.CanSet() || field.Anonymous) {
+			p := prefix
+			if tag != "" {
+				p = prefix + tag + "."
+			}
+			bindStruct(fv, p, snap, merr)
+			continue
+		}
+
+		if !hasTag || tag == "" || !fv.CanSet() {
+			continue
+		}
+
+		name := prefix + tag
+		s, err := snap.get(name)
+		if err == ErrNotFound {
+			def, ok := field.Tag.Lookup(TagDefault)
+			if !ok {
+				continue
+			}
+			s = def
+		} else if err != nil {
+			merr.Append(fmt.Errorf("error binding '%s' to field %s: %v", name, field.Name, err))
+			continue
+		}
+
+		if err := setField(fv, s); err != nil {
+			merr.Append(fmt.Errorf("error binding '%s' to field %s: %v", name, field.Name, err))
+		}
+	}
+}
+
+// isNested returns true if a field of type `t` is bound as a nested struct
+// rather than from a single property. Structs with no exported fields, such
+// as `time.Time`, are bound as a single property.
+func isNested(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath == "" || f.Anonymous {
+			return true
+		}
+	}
+	return false
+}
+
+// setField parses a string and assigns the result to a field value.
+func setField(fv reflect.Value, s string) error {
+	if fv.Type() == durationType {
+		d, err := parseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	if fv.Type() == timeType {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(tm))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package cfg
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wiggin77/merror"
+)
+
+type bindDB struct {
+	Host    string        `cfg:"host" default:"localhost"`
+	Port    uint16        `cfg:"port" default:"5432"`
+	Timeout time.Duration `cfg:"timeout" default:"30 seconds"`
+}
+
+type bindEmbedded struct {
+	Ratio float32 `cfg:"ratio"`
+}
+
+type bindApp struct {
+	bindEmbedded
+	Name     string `cfg:"name"`
+	Retries  int    `cfg:"retries" default:"3"`
+	Debug    bool   `cfg:"debug"`
+	Big      int64  `cfg:"big"`
+	Skipped  string `cfg:"-"`
+	Untagged string
+	Keep     string `cfg:"missing"`
+	DB       bindDB `cfg:"db"`
+	private  string `cfg:"name"`
+}
+
+func TestConfig_Bind(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(NewSrcMapFromMap(map[string]string{
+		"name":       "myapp",
+		"debug":      "yes",
+		"big":        "2147483647000",
+		"ratio":      "1.5",
+		"Skipped":    "x",
+		"Untagged":   "x",
+		"db.host":    "db.example.com",
+		"db.timeout": "1 minute",
+	}))
+
+	app := bindApp{Keep: "keep"}
+	if err := config.Bind(&app); err != nil {
+		t.Fatalf("Bind; expected err=nil, got err=%v", err)
+	}
+
+	want := bindApp{
+		bindEmbedded: bindEmbedded{Ratio: 1.5},
+		Name:         "myapp",
+		Retries:      3,
+		Debug:        true,
+		Big:          2147483647000,
+		Keep:         "keep",
+		DB:           bindDB{Host: "db.example.com", Port: 5432, Timeout: time.Minute},
+	}
+	if app != want {
+		t.Errorf("Bind; got %+v, expected %+v", app, want)
+	}
+}
+
+func TestConfig_BindErrors(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(NewSrcMapFromMap(map[string]string{
+		"retries": "lots",
+		"debug":   "maybe",
+		"db.port": "70000",
+	}))
+
+	app := bindApp{}
+	err := config.Bind(&app)
+	if err == nil {
+		t.Fatal("Bind; expected error")
+	}
+	merr, ok := err.(*merror.MError)
+	if !ok {
+		t.Fatalf("Bind; expected *merror.MError, got %T", err)
+	}
+	if merr.Len() != 3 {
+		t.Errorf("Bind; expected 3 errors, got %d: %v", merr.Len(), err)
+	}
+	for _, key := range []string{"'retries'", "'debug'", "'db.port'"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Bind; expected error to mention %s, got %v", key, err)
+		}
+	}
+
+	// fields that parsed successfully are still bound.
+	if app.DB.Host != "localhost" {
+		t.Errorf("Bind; expected db.host=localhost, got %s", app.DB.Host)
+	}
+
+	if err := config.Bind(app); err == nil {
+		t.Error("Bind; expected error for non-pointer")
+	}
+}
+
+type bindOpaque struct {
+	n int
+}
+
+func TestConfig_BindLeafStructs(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(NewSrcMapFromMap(map[string]string{
+		"start":  "2024-03-01T10:30:00Z",
+		"opaque": "x",
+	}))
+
+	var ok struct {
+		Start time.Time `cfg:"start"`
+		Stop  time.Time `cfg:"stop" default:"2024-03-02T00:00:00.5+02:00"`
+	}
+	if err := config.Bind(&ok); err != nil {
+		t.Fatalf("Bind; expected err=nil, got err=%v", err)
+	}
+	if want := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC); !ok.Start.Equal(want) {
+		t.Errorf("Bind; got start=%v, expected %v", ok.Start, want)
+	}
+	if want := time.Date(2024, 3, 1, 22, 0, 0, 500000000, time.UTC); !ok.Stop.Equal(want) {
+		t.Errorf("Bind; got stop=%v, expected %v", ok.Stop, want)
+	}
+
+	var bad struct {
+		Opaque bindOpaque `cfg:"opaque"`
+	}
+	err := config.Bind(&bad)
+	if err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Errorf("Bind; expected unsupported type error, got %v", err)
+	}
+}
+
+type bindGen struct {
+	A string `cfg:"a"`
+	B string `cfg:"b"`
+	C string `cfg:"c"`
+	D string `cfg:"d"`
+	E string `cfg:"e"`
+	F string `cfg:"f"`
+}
+
+func TestConfig_BindConsistent(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+	gen := func(i int) Source {
+		v := strconv.Itoa(i)
+		return NewSrcMapFromMap(map[string]string{"a": v, "b": v, "c": v, "d": v, "e": v, "f": v})
+	}
+	if err := config.AppendNamedSource("gen", gen(0)); err != nil {
+		t.Fatalf("AppendNamedSource; expected err=nil, got err=%v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 2000; i++ {
+			_ = config.ReplaceSource("gen", gen(i))
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		var v bindGen
+		if err := config.Bind(&v); err != nil {
+			t.Fatalf("Bind; expected err=nil, got err=%v", err)
+		}
+		if v.B != v.A || v.C != v.A || v.D != v.A || v.E != v.A || v.F != v.A {
+			t.Fatalf("Bind; fields from different generations: %+v", v)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package cfg
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -17,8 +18,13 @@ import (
 var ErrNotFound = errors.New("not found")
 
 type sourceEntry struct {
-	src   Source
-	props map[string]string
+	name       string
+	src        Source
+	props      map[string]string
+	stop       chan struct{} // closed when the source is removed, to stop monitoring
+	lastLoaded time.Time
+	lastErr    *SourceError
+	errCount   int
 }
 
 // Config provides methods for retrieving property values from one or more
@@ -26,29 +32,43 @@ type sourceEntry struct {
 type Config struct {
 	mutexSrc         sync.RWMutex
 	mutexListeners   sync.RWMutex
+	mutexLog         sync.RWMutex
 	srcs             []*sourceEntry
+	resolved         map[string]string // replaced, never modified; see `snapshot`
+	effective        map[string]string
+	effectiveErrs    map[string]error
+	envRefs          map[string]bool // properties whose values reference environment variables
 	chgListeners     []ChangedListener
 	shutdown         chan interface{}
+	stopped          bool
+	monitors         sync.WaitGroup
+	ctx              context.Context
 	wantPanicOnError bool
+	wantInterpolate  bool
+	preserveSpace    bool
+	logger           Logger
+	secretPatterns   []string
+}
+
+// NewConfigWithContext creates a new `Config` which stops monitoring all
+// config sources when `ctx` is done, as if `Shutdown` was called.
+// A `Config` created with `&Config{}` is monitored until `Shutdown`.
+func NewConfigWithContext(ctx context.Context) *Config {
+	config := &Config{ctx: ctx}
+	config.mutexSrc.Lock()
+	config.watchContext()
+	config.mutexSrc.Unlock()
+	return config
 }
 
 // PrependSource inserts one or more `Sources` at the beginning of
 // the list of sources such that the first source will be the
 // source checked first when resolving a property value.
 func (config *Config) PrependSource(srcs ...Source) {
-	arr := config.wrapSources(srcs...)
-
-	config.mutexSrc.Lock()
-	if config.shutdown == nil {
-		config.shutdown = make(chan interface{})
-	}
-	config.srcs = append(arr, config.srcs...)
-	config.mutexSrc.Unlock()
-
-	for _, se := range arr {
-		if _, ok := se.src.(SourceMonitored); ok {
-			config.monitor(se)
-		}
+	arr, errs := config.wrapSources(srcs...)
+	for i, se := range arr {
+		idx := i
+		_ = config.insertSource(se, errs[i], func() (int, error) { return idx, nil })
 	}
 }
 
@@ -56,37 +76,105 @@ func (config *Config) PrependSource(srcs ...Source) {
 // the list of sources such that the last source will be the
 // source checked last when resolving a property value.
 func (config *Config) AppendSource(srcs ...Source) {
-	arr := config.wrapSources(srcs...)
+	arr, errs := config.wrapSources(srcs...)
+	for i, se := range arr {
+		_ = config.insertSource(se, errs[i], config.end)
+	}
+}
 
+// insertSource inserts a `sourceEntry` at the index within the list of
+// sources returned by `where`, which is called with `mutexSrc` locked.
+// Listeners are notified of any properties whose effective value changed as
+// a result, and of `err` if the source failed to load. Monitoring is started
+// if the source supports it.
+//
+// Returns `ErrSourceExists` if a source with the same name exists, or the
+// error returned by `where`.
+func (config *Config) insertSource(se *sourceEntry, err *SourceError, where func() (int, error)) error {
 	config.mutexSrc.Lock()
-	if config.shutdown == nil {
-		config.shutdown = make(chan interface{})
+	if se.name != "" && config.indexOf(se.name) != -1 {
+		config.mutexSrc.Unlock()
+		return ErrSourceExists
+	}
+	idx, errWhere := where()
+	if errWhere != nil {
+		config.mutexSrc.Unlock()
+		return errWhere
 	}
-	config.srcs = append(config.srcs, arr...)
+	config.srcs = append(config.srcs, nil)
+	copy(config.srcs[idx+1:], config.srcs[idx:])
+	config.srcs[idx] = se
+	changes := config.updateEffective()
+	config.monitor(se)
+	keys := len(se.props)
 	config.mutexSrc.Unlock()
 
-	for _, se := range arr {
-		if _, ok := se.src.(SourceMonitored); ok {
-			config.monitor(se)
-		}
+	config.logLoaded(se, idx, keys, changes)
+	if err != nil {
+		config.onSourceError(err)
+	}
+	config.onSourceAdded(se, changes)
+	return nil
+}
+
+// logLoaded logs a source being added to the list of sources at `idx`.
+func (config *Config) logLoaded(se *sourceEntry, idx int, keys int, changes []PropChange) {
+	log := config.getLogger()
+	log.Info("config source loaded", "source", se.String(), "index", idx, "keys", keys, "changes", len(changes))
+	config.logChanges(log, se, changes)
+}
+
+// isRemoved returns true if the source has been removed from the list of
+// sources.
+func (se *sourceEntry) isRemoved() bool {
+	select {
+	case <-se.stop:
+		return true
+	default:
+		return false
 	}
 }
 
+// end returns the index of the end of the list of sources.
+//
+// Must be called with `mutexSrc` locked.
+func (config *Config) end() (int, error) {
+	return len(config.srcs), nil
+}
+
 // wrapSources wraps one or more Source's and returns
-// them as an array of `sourceEntry`.
-func (config *Config) wrapSources(srcs ...Source) []*sourceEntry {
+// them as an array of `sourceEntry`, along with any error
+// loading each source.
+func (config *Config) wrapSources(srcs ...Source) ([]*sourceEntry, []*SourceError) {
 	arr := make([]*sourceEntry, 0, len(srcs))
+	errs := make([]*SourceError, 0, len(srcs))
 	for _, src := range srcs {
-		se := &sourceEntry{src: src}
-		config.reloadProps(se)
+		se, err := config.wrapNamedSource("", src)
 		arr = append(arr, se)
+		errs = append(errs, err)
+	}
+	return arr, errs
+}
+
+// wrapNamedSource wraps a Source and loads its properties. Panics if
+// loading fails and `wantPanicOnError` is set.
+func (config *Config) wrapNamedSource(name string, src Source) (*sourceEntry, *SourceError) {
+	config.mutexSrc.Lock()
+	defer config.mutexSrc.Unlock()
+
+	se := &sourceEntry{name: name, src: src, stop: make(chan struct{})}
+	err := config.loadProps(se)
+	if err != nil && config.wantPanicOnError {
+		panic(fmt.Sprintf("GetProps error for %v: %v", src, config.redactErr(err.Err)))
 	}
-	return arr
+	return se, err
 }
 
 // SetWantPanicOnError sets the flag determining if Config
-// should panic when `GetProps` or `GetLastModified` errors
-// for a `Source`.
+// should panic when `GetProps` errors while adding a `Source`.
+// The panic occurs on the goroutine adding the source. Errors
+// occurring while monitoring sources never panic and are
+// reported to listeners implementing `ErrorListener` instead.
 func (config *Config) SetWantPanicOnError(b bool) {
 	config.mutexSrc.Lock()
 	config.wantPanicOnError = b
@@ -94,8 +182,7 @@ func (config *Config) SetWantPanicOnError(b bool) {
 }
 
 // ShouldPanicOnError gets the flag determining if Config
-// should panic when `GetProps` or `GetLastModified` errors
-// for a `Source`.
+// should panic when `GetProps` errors while adding a `Source`.
 func (config *Config) ShouldPanicOnError() (b bool) {
 	config.mutexSrc.RLock()
 	b = config.wantPanicOnError
@@ -103,31 +190,143 @@ func (config *Config) ShouldPanicOnError() (b bool) {
 	return b
 }
 
-// getProp returns the value of a named property.
-// Each `Source` is checked, in the order created by adding via
-// `AppendSource` and `PrependSource`, until a value for the
-// property is found.
-func (config *Config) getProp(name string) (val string, ok bool) {
+// SetWantInterpolation sets the flag determining if Config
+// expands references to other properties and environment variables
+// within property values, such as `http://${server.host}:${server.port}`.
+//
+// Supported syntax:
+//
+//	${name}          value of property `name`, itself interpolated
+//	${name:-def}     value of property `name`, or `def` if not found
+//	${env:NAME}      value of environment variable `NAME`
+//	${env:NAME:-def} value of environment variable `NAME`, or `def` if not set
+//	$${              literal `${`
+//
+// References are resolved through all sources and re-evaluated whenever
+// any source changes, meaning listeners are notified when a referenced
+// property changes. Cyclic references result in an error being returned
+// when fetching the property.
+//
+// Environment variables are looked up each time a property referencing them
+// is fetched. Changes to environment variables are not monitored, meaning
+// listeners are only notified of them when a source changes.
+//
+// Listeners are notified of any properties whose effective value changed as
+// a result of changing the flag.
+func (config *Config) SetWantInterpolation(b bool) {
+	config.mutexSrc.Lock()
+	config.wantInterpolate = b
+	changes := config.updateEffective()
+	config.mutexSrc.Unlock()
+
+	config.onOptionChanged(changes)
+}
+
+// ShouldInterpolate gets the flag determining if Config
+// expands references within property values.
+func (config *Config) ShouldInterpolate() (b bool) {
 	config.mutexSrc.RLock()
-	defer config.mutexSrc.RUnlock()
+	b = config.wantInterpolate
+	config.mutexSrc.RUnlock()
+	return b
+}
 
-	var s string
-	for _, se := range config.srcs {
-		if se.props != nil {
-			if s, ok = se.props[name]; ok {
-				val = strings.TrimSpace(s)
-				return
+// SetWantPreserveSpace sets the flag determining if Config
+// preserves leading and trailing whitespace in property values.
+// By default whitespace is removed. Sources may also remove
+// whitespace; for example INI files require values containing
+// leading or trailing whitespace to be quoted.
+//
+// Listeners are notified of any properties whose effective value changed as
+// a result of changing the flag.
+func (config *Config) SetWantPreserveSpace(b bool) {
+	config.mutexSrc.Lock()
+	config.preserveSpace = b
+	changes := config.updateEffective()
+	config.mutexSrc.Unlock()
+
+	config.onOptionChanged(changes)
+}
+
+// ShouldPreserveSpace gets the flag determining if Config
+// preserves leading and trailing whitespace in property values.
+func (config *Config) ShouldPreserveSpace() (b bool) {
+	config.mutexSrc.RLock()
+	b = config.preserveSpace
+	config.mutexSrc.RUnlock()
+	return b
+}
+
+// getProp returns the effective value of a named property, or an
+// error if the value could not be interpolated.
+// See `resolveProps`.
+func (config *Config) getProp(name string) (val string, ok bool, err error) {
+	return config.snapshot().value(name)
+}
+
+// resolveProps returns a map of all property names to their effective
+// values. Each `Source` is checked, in the order created by adding via
+// `AppendSource` and `PrependSource`, until a value for the property is
+// found. Whitespace is removed from values unless `preserveSpace` is set.
+//
+// Must be called with `mutexSrc` locked.
+func (config *Config) resolveProps() map[string]string {
+	m := make(map[string]string)
+	for i := len(config.srcs) - 1; i >= 0; i-- {
+		for k, v := range config.srcs[i].props {
+			if !config.preserveSpace {
+				v = strings.TrimSpace(v)
 			}
+			m[k] = v
 		}
 	}
-	return
+	return m
+}
+
+// updateEffective recalculates the effective value of every property and
+// returns the properties whose effective value changed.
+//
+// Must be called with `mutexSrc` locked.
+func (config *Config) updateEffective() []PropChange {
+	resolved := config.resolveProps()
+	m := resolved
+	errs := make(map[string]error)
+	var envRefs map[string]bool
+
+	if config.wantInterpolate {
+		ip := newInterpolator(resolved)
+		expanded := make(map[string]string, len(resolved))
+		for k, raw := range resolved {
+			val, _, err := ip.resolve(k)
+			if err != nil {
+				errs[k] = err
+				val = raw
+			}
+			expanded[k] = val
+		}
+		m = expanded
+		envRefs = ip.env
+	}
+
+	changes := diffProps(config.effective, m)
+	config.resolved = resolved
+	config.effective = m
+	config.effectiveErrs = errs
+	config.envRefs = envRefs
+	return changes
 }
 
 // String returns the value of the named prop as a string.
 // If the property is not found then the supplied default `def`
-// and `ErrNotFound` are returned.
+// and `ErrNotFound` are returned. If interpolation is enabled and the
+// value cannot be interpolated then `def` and the error are returned.
 func (config *Config) String(name string, def string) (val string, err error) {
-	if v, ok := config.getProp(name); ok {
+	if v, ok, e := config.getProp(name); ok {
+		if e != nil {
+			val = def
+			err = e
+			return
+		}
 		val = v
 		err = nil
 		return
@@ -146,10 +345,7 @@ func (config *Config) String(name string, def string) (val string, err error) {
 func (config *Config) Int(name string, def int) (val int, err error) {
 	var s string
 	if s, err = config.String(name, ""); err == nil {
-		var i int64
-		if i, err = strconv.ParseInt(s, 10, 32); err == nil {
-			val = int(i)
-		}
+		val, err = parseInt(s)
 	}
 	if err != nil {
 		val = def
@@ -200,14 +396,7 @@ func (config *Config) Float64(name string, def float64) (val float64, err error)
 func (config *Config) Bool(name string, def bool) (val bool, err error) {
 	var s string
 	if s, err = config.String(name, ""); err == nil {
-		switch strings.ToLower(s) {
-		case "t", "true", "1", "y", "yes":
-			val = true
-		case "f", "false", "0", "n", "no":
-			val = false
-		default:
-			err = errors.New("invalid syntax")
-		}
+		val, err = parseBool(s)
 	}
 	if err != nil {
 		val = def
@@ -228,9 +417,7 @@ func (config *Config) Bool(name string, def bool) (val bool, err error) {
 func (config *Config) Duration(name string, def time.Duration) (val time.Duration, err error) {
 	var s string
 	if s, err = config.String(name, ""); err == nil {
-		var ms int64
-		ms, err = timeconv.ParseMilliseconds(s)
-		val = time.Duration(ms) * time.Millisecond
+		val, err = parseDuration(s)
 	}
 	if err != nil {
 		val = def
@@ -238,6 +425,37 @@ func (config *Config) Duration(name string, def time.Duration) (val time.Duratio
 	return
 }
 
+// parseInt parses a string containing a 32 bit signed integer.
+func parseInt(s string) (int, error) {
+	i, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return int(i), nil
+}
+
+// parseBool parses a string containing a boolean.
+// See config.Bool for supported values.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "t", "true", "1", "y", "yes":
+		return true, nil
+	case "f", "false", "0", "n", "no":
+		return false, nil
+	}
+	return false, errors.New("invalid syntax")
+}
+
+// parseDuration parses a string containing a number plus optional
+// unit of measure. See config.Duration.
+func parseDuration(s string) (time.Duration, error) {
+	ms, err := timeconv.ParseMilliseconds(s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
 // AddChangedListener adds a listener that will receive notifications
 // whenever one or more property values change within the config.
 func (config *Config) AddChangedListener(l ChangedListener) {
@@ -270,74 +488,313 @@ func (config *Config) RemoveChangedListener(l ChangedListener) error {
 }
 
 // Shutdown can be called to stop monitoring of all config sources.
+// Sources added after shutdown are not monitored. Shutdown can be called
+// more than once, and returns without waiting for monitoring to stop;
+// see `Close`.
 func (config *Config) Shutdown() {
-	config.mutexSrc.RLock()
-	defer config.mutexSrc.RUnlock()
+	config.mutexSrc.Lock()
+	defer config.mutexSrc.Unlock()
+	if config.stopped {
+		return
+	}
+	config.stopped = true
 	if config.shutdown != nil {
 		close(config.shutdown)
+		config.shutdown = nil
+	}
+}
+
+// Close stops monitoring of all config sources, like `Shutdown`, and waits
+// for monitoring to stop or for `ctx` to be done, in which case the context's
+// error is returned.
+func (config *Config) Close(ctx context.Context) error {
+	config.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		config.monitors.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
+// RestartMonitoring resumes monitoring of all config sources after
+// `Shutdown` or `Close`. It does nothing if monitoring has not been stopped.
+// Returns the context's error if the config was created via
+// `NewConfigWithContext` and the context is done.
+//
+// RestartMonitoring must not be called while `Close` is waiting.
+func (config *Config) RestartMonitoring() error {
+	config.mutexSrc.Lock()
+	defer config.mutexSrc.Unlock()
+
+	if !config.stopped {
+		return nil
+	}
+	if config.ctx != nil {
+		if err := config.ctx.Err(); err != nil {
+			return err
+		}
+	}
+	config.stopped = false
+	config.watchContext()
+	for _, se := range config.srcs {
+		config.monitor(se)
+	}
+	return nil
+}
+
+// watchContext starts a goroutine that calls `Shutdown` when the context
+// passed to `NewConfigWithContext` is done. The goroutine exits when the
+// config is shut down.
+//
+// Must be called with `mutexSrc` locked.
+func (config *Config) watchContext() {
+	if config.ctx == nil {
+		return
+	}
+	if config.shutdown == nil {
+		config.shutdown = make(chan interface{})
+	}
+	go func(ctx context.Context, shutdown <-chan interface{}) {
+		select {
+		case <-ctx.Done():
+			config.Shutdown()
+		case <-shutdown:
+		}
+	}(config.ctx, config.shutdown)
+}
+
 // onSourceChanged is called whenever one or more properties of a
-// config source has changed.
-func (config *Config) onSourceChanged(src SourceMonitored) {
+// config source has changed. Listeners implementing `ChangedPropsListener`
+// receive the list of properties whose effective value changed, and are
+// skipped if the list is empty.
+func (config *Config) onSourceChanged(se *sourceEntry, changes []PropChange) {
+	config.notifyListeners(se, changes, true)
+}
+
+// onSourceAdded is called whenever a source is added to the config.
+// Only listeners implementing `ChangedPropsListener` are notified.
+func (config *Config) onSourceAdded(se *sourceEntry, changes []PropChange) {
+	config.notifyListeners(se, changes, false)
+}
+
+// onSourceRemoved is called whenever a source is removed from the config.
+// Only listeners implementing `ChangedPropsListener` are notified.
+func (config *Config) onSourceRemoved(se *sourceEntry, changes []PropChange) {
+	config.notifyListeners(se, changes, false)
+}
+
+// onOptionChanged is called whenever changing an option, such as
+// interpolation, changes the effective value of one or more properties.
+// Only listeners implementing `ChangedPropsListener` are notified, with
+// a nil source.
+func (config *Config) onOptionChanged(changes []PropChange) {
+	config.notifyListeners(&sourceEntry{}, changes, false)
+}
+
+// onSourceError is called whenever an error involving a config source
+// occurs. Only listeners implementing `ErrorListener` are notified.
+func (config *Config) onSourceError(serr *SourceError) {
+	log := config.getLogger()
+	log.Error("config source error", "source", sourceDesc(serr.Name, serr.Source), "op", string(serr.Op),
+		"error", config.redactErr(serr.Err))
+
+	config.mutexListeners.RLock()
+	defer config.mutexListeners.RUnlock()
+	for _, l := range config.chgListeners {
+		if el, ok := l.(ErrorListener); ok {
+			func() {
+				defer func() {
+					// nowhere left to report a panicking error listener except the log.
+					if p := recover(); p != nil {
+						log.Error("config error listener panicked", "listener", fmt.Sprintf("%T", el), "panic", p)
+					}
+				}()
+				el.ConfigError(config, serr.Source, serr)
+			}()
+		}
+	}
+}
+
+// notifyListeners calls each listener with the list of changed properties.
+// Listeners not implementing `ChangedPropsListener` are only called if
+// `all` is true. Listeners that panic are reported via `onSourceError`,
+// and do not prevent other listeners being called.
+func (config *Config) notifyListeners(se *sourceEntry, changes []PropChange, all bool) {
+	var panics []*SourceError
+
+	config.mutexListeners.RLock()
+	for _, l := range config.chgListeners {
+		if err := config.callListener(se, l, changes, all); err != nil {
+			panics = append(panics, err)
+		}
+	}
+	config.mutexListeners.RUnlock()
+
+	for _, err := range panics {
+		config.onSourceError(err)
+	}
+}
+
+// callListener calls a listener with the list of changed properties,
+// returning a `SourceError` if the listener panics.
+func (config *Config) callListener(se *sourceEntry, l ChangedListener, changes []PropChange, all bool) (serr *SourceError) {
 	defer func() {
 		if p := recover(); p != nil {
-			fmt.Println(p)
+			serr = se.newSourceError(OpListener, fmt.Errorf("listener %T panicked: %v", l, p))
 		}
 	}()
-	config.mutexListeners.RLock()
-	defer config.mutexListeners.RUnlock()
-	for _, l := range config.chgListeners {
-		l.ConfigChanged(config, src)
+
+	if pl, ok := l.(ChangedPropsListener); ok {
+		if len(changes) > 0 {
+			pl.PropsChanged(config, se.src, changes)
+		}
+	} else if all {
+		if sm, ok := se.src.(SourceMonitored); ok {
+			l.ConfigChanged(config, sm)
+		}
 	}
+	return nil
 }
 
-// monitor periodically checks a config source for changes.
+// pausedMonitorFreq is the frequency `GetMonitorFreq` is checked
+// while monitoring of a source is suspended. A variable for testing.
+var pausedMonitorFreq = 10 * time.Second
+
+// monitor starts a goroutine that periodically checks a config source for
+// changes, if the source implements `SourceMonitored` and the config has not
+// been shut down. Sources implementing `SourceNotifier` are checked whenever
+// they push a notification instead of being polled.
+//
+// Must be called with `mutexSrc` locked.
 func (config *Config) monitor(se *sourceEntry) {
+	src, ok := se.src.(SourceMonitored)
+	if !ok || config.stopped {
+		return
+	}
+	if config.shutdown == nil {
+		config.shutdown = make(chan interface{})
+	}
+	config.monitors.Add(1)
+
 	go func(se *sourceEntry, shutdown <-chan interface{}) {
-		var src SourceMonitored
-		var ok bool
-		if src, ok = se.src.(SourceMonitored); !ok {
-			return
-		}
+		defer config.monitors.Done()
+
 		paused := false
 		last := time.Time{}
 		freq := src.GetMonitorFreq()
 		if freq <= 0 {
 			paused = true
-			freq = 10
+			freq = pausedMonitorFreq
 			last, _ = src.GetLastModified()
+			config.getLogger().Info("config source monitoring paused", "source", se.String())
+		}
+
+		check := func() {
+			if latest, err := src.GetLastModified(); err != nil {
+				config.onSourceError(config.sourceFailed(se, OpGetLastModified, err))
+			} else {
+				config.sourceRecovered(se, OpGetLastModified)
+				if last.Before(latest) {
+					last = latest
+					changes, err := config.reloadProps(se)
+					if se.isRemoved() {
+						return
+					}
+					if err != nil {
+						config.onSourceError(err)
+					}
+					config.onSourceChanged(se, changes)
+				}
+			}
 		}
+
 		timer := time.NewTimer(freq)
+		polling := true
+		pending := false // notified of a change while paused
+
+		var events <-chan struct{}
+		if sn, ok := src.(SourceNotifier); ok {
+			done := make(chan struct{})
+			defer close(done)
+			if ch, err := sn.Notify(done); err == nil {
+				events = ch
+				polling = false
+				if !timer.Stop() {
+					<-timer.C
+				}
+				// catch changes made since the source was loaded, before
+				// watching started.
+				if !paused {
+					check()
+				} else {
+					pending = true
+					timer.Reset(pausedMonitorFreq)
+				}
+			}
+		}
+
 		for {
 			select {
+			case _, ok := <-events:
+				if !ok {
+					// notifications stopped; fall back to polling.
+					events = nil
+					polling = true
+					pending = false
+					timer.Reset(freq)
+					continue
+				}
+				if src.GetMonitorFreq() > 0 {
+					check()
+				} else if !pending {
+					// paused; poll for monitoring to resume, then check.
+					pending = true
+					timer.Reset(pausedMonitorFreq)
+				}
 			case <-timer.C:
-				if !paused {
-					if latest, err := src.GetLastModified(); err != nil {
-						if config.ShouldPanicOnError() {
-							panic(fmt.Sprintf("error <%v> getting last modified for %v", err, src))
-						}
+				if !polling {
+					if src.GetMonitorFreq() > 0 {
+						pending = false
+						check()
 					} else {
-						if last.Before(latest) {
-							last = latest
-							config.reloadProps(se)
-							// TODO: calc diff and provide detailed changes
-							config.onSourceChanged(src)
-						}
+						timer.Reset(pausedMonitorFreq)
 					}
+					continue
+				}
+				if !paused {
+					check()
 				}
 				freq = src.GetMonitorFreq()
 				if freq <= 0 {
+					if !paused {
+						config.getLogger().Info("config source monitoring paused", "source", se.String())
+					}
 					paused = true
-					freq = 10
+					freq = pausedMonitorFreq
 				} else {
+					if paused {
+						config.getLogger().Info("config source monitoring resumed", "source", se.String(), "freq", freq)
+					}
 					paused = false
 				}
 				timer.Reset(freq)
 			case <-shutdown:
 				// stop the timer and exit
-				if !timer.Stop() {
+				if polling && !timer.Stop() {
+					<-timer.C
+				}
+				return
+			case <-se.stop:
+				// source removed; stop the timer and exit
+				if polling && !timer.Stop() {
 					<-timer.C
 				}
 				return
@@ -346,21 +803,47 @@ func (config *Config) monitor(se *sourceEntry) {
 	}(se, config.shutdown)
 }
 
-// reloadProps causes a Source to reload its properties.
-func (config *Config) reloadProps(se *sourceEntry) {
+// reloadProps causes a Source to reload its properties and returns
+// the properties whose effective value changed as a result, plus any
+// error returned by the source.
+func (config *Config) reloadProps(se *sourceEntry) ([]PropChange, *SourceError) {
 	config.mutexSrc.Lock()
-	defer config.mutexSrc.Unlock()
+	err := config.loadProps(se)
+	changes := config.updateEffective()
+	keys := len(se.props)
+	config.mutexSrc.Unlock()
+
+	log := config.getLogger()
+	log.Info("config source reloaded", "source", se.String(), "keys", keys, "changes", len(changes))
+	config.logChanges(log, se, changes)
+	return changes, err
+}
 
+// loadProps fetches the properties from a Source and stores a copy
+// in the `sourceEntry`. If the source returns an error then the previous
+// properties are retained, unless the error is a `*PartialLoadError` in
+// which case the properties returned are used.
+//
+// The error, if any, is recorded as part of the source's health.
+//
+// Must be called with `mutexSrc` locked.
+func (config *Config) loadProps(se *sourceEntry) *SourceError {
 	m, err := se.src.GetProps()
+	var serr *SourceError
 	if err != nil {
-		if config.wantPanicOnError {
-			panic(fmt.Sprintf("GetProps error for %v", se.src))
+		pe, partial := err.(*PartialLoadError)
+		if !partial {
+			return se.failed(OpGetProps, err)
 		}
-		return
+		serr = se.failed(OpGetProps, pe.Err)
+	} else {
+		se.lastLoaded = time.Now()
+		se.errCount = 0
 	}
 
 	se.props = make(map[string]string)
 	for k, v := range m {
 		se.props[k] = v
 	}
+	return serr
 }
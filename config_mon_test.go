@@ -1,11 +1,20 @@
 package cfg
 
 import (
+	"context"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/wiggin77/cfg/ini"
 )
 
 func makeSrc(freq time.Duration) *SrcMap {
@@ -73,3 +82,431 @@ func TestConfig_Monitor(t *testing.T) {
 		t.Errorf("ChangedListener was called %d times after being removed.", count)
 	}
 }
+
+type NotifyProps struct {
+	mutex   sync.Mutex
+	changes []PropChange
+}
+
+func (n *NotifyProps) ConfigChanged(cfg *Config, src SourceMonitored) {
+	panic("ConfigChanged should not be called for ChangedPropsListener")
+}
+
+func (n *NotifyProps) PropsChanged(cfg *Config, src Source, changes []PropChange) {
+	n.mutex.Lock()
+	n.changes = append(n.changes, changes...)
+	n.mutex.Unlock()
+}
+
+func (n *NotifyProps) getChanges() []PropChange {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.changes
+}
+
+func TestConfig_MonitorProps(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+
+	mapSrc := makeSrc(time.Millisecond * 10)
+	config.AppendSource(mapSrc)
+
+	notify := &NotifyProps{}
+	config.AddChangedListener(notify)
+
+	mapSrc.PutAll(map[string]string{"prop1": "one", "prop5": "5", "prop2": "2"})
+	time.Sleep(100 * time.Millisecond)
+
+	want := []PropChange{
+		{Name: "prop1", Type: PropModified, OldVal: "1", NewVal: "one"},
+		{Name: "prop5", Type: PropAdded, NewVal: "5"},
+	}
+	got := notify.getChanges()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PropsChanged got %v; expected %v", got, want)
+	}
+}
+
+func TestConfig_MonitorPropsEffective(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+
+	low := makeSrc(time.Millisecond * 10)
+	config.AppendSource(low)
+
+	notify := &NotifyProps{}
+	config.AddChangedListener(notify)
+
+	// prepending a source that shadows prop1 changes its effective value.
+	high := NewSrcMapFromMap(map[string]string{"prop1": "high"})
+	high.SetMonitorFreq(time.Millisecond * 10)
+	config.PrependSource(high)
+
+	// changes to the shadowed prop1 in the lower source are not reported.
+	low.PutAll(map[string]string{"prop1": "low", "prop2": "two"})
+	time.Sleep(100 * time.Millisecond)
+
+	want := []PropChange{
+		{Name: "prop1", Type: PropModified, OldVal: "1", NewVal: "high"},
+		{Name: "prop2", Type: PropModified, OldVal: "2", NewVal: "two"},
+	}
+	got := notify.getChanges()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PropsChanged got %v; expected %v", got, want)
+	}
+}
+
+func TestConfig_MonitorNotify(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("change notification only supported on linux")
+	}
+
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.conf")
+	writeTestFile(t, filespec, "prop1=1\n")
+
+	src, err := NewSrcFileFromFilespec(filespec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// polling would never detect the change within the test.
+	src.SetMonitorFreq(time.Hour)
+
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(src)
+
+	changed := make(chan PropChange, 10)
+	config.Watch("prop1", func(chg PropChange) { changed <- chg })
+
+	// give the monitor time to start watching
+	time.Sleep(50 * time.Millisecond)
+
+	tmp := filespec + ".tmp"
+	writeTestFile(t, tmp, "prop1=2\n")
+	if err := os.Rename(tmp, filespec); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case chg := <-changed:
+		if chg.NewVal != "2" {
+			t.Errorf("Watch; expected prop1=2, got %v", chg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("change not pushed within 2 seconds")
+	}
+}
+
+func TestConfig_MonitorNotifyIncludes(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("change notification only supported on linux")
+	}
+
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "shared"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	filespec := filepath.Join(dir, "test.conf")
+	base := filepath.Join(dir, "shared", "base.conf")
+	writeTestFile(t, filespec, "prop1=1\n!include shared/base.conf\n")
+	writeTestFile(t, base, "prop2=1\n")
+
+	src, err := NewSrcFileFromFilespec(filespec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// polling would never detect the change within the test.
+	src.SetMonitorFreq(time.Hour)
+
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(src)
+
+	changed := make(chan PropChange, 10)
+	config.Watch("prop2", func(chg PropChange) { changed <- chg })
+
+	// give the monitor time to start watching
+	time.Sleep(50 * time.Millisecond)
+
+	tmp := base + ".tmp"
+	writeTestFile(t, tmp, "prop2=2\n")
+	if err := os.Rename(tmp, base); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case chg := <-changed:
+		if chg.NewVal != "2" {
+			t.Errorf("Watch; expected prop2=2, got %v", chg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("change to included file not pushed within 2 seconds")
+	}
+
+	// include a file from a directory not previously watched
+	if err := os.Mkdir(filepath.Join(dir, "other"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other", "other.conf")
+	writeTestFile(t, other, "prop3=1\n")
+	writeTestFile(t, filespec, "prop1=1\n!include shared/base.conf\n!include other/other.conf\n")
+	config.Watch("prop3", func(chg PropChange) { changed <- chg })
+
+	// wait for the new include to be loaded and watched
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("new include not loaded within 2 seconds")
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	writeTestFile(t, other, "prop3=2\n")
+	select {
+	case chg := <-changed:
+		if chg.NewVal != "2" {
+			t.Errorf("Watch; expected prop3=2, got %v", chg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("change to newly included file not pushed within 2 seconds")
+	}
+}
+
+func TestConfig_MonitorNotifyPaused(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("change notification only supported on linux")
+	}
+
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.conf")
+	writeTestFile(t, filespec, "prop1=1\n")
+
+	src, err := NewSrcFileFromFilespec(filespec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// polling would never detect the change within the test.
+	src.SetMonitorFreq(time.Hour)
+
+	prev := pausedMonitorFreq
+	pausedMonitorFreq = 20 * time.Millisecond
+	config := &Config{}
+	defer func() {
+		_ = config.Close(context.Background())
+		pausedMonitorFreq = prev
+	}()
+	config.AppendSource(src)
+
+	changed := make(chan PropChange, 10)
+	config.Watch("prop1", func(chg PropChange) { changed <- chg })
+
+	// give the monitor time to start watching
+	time.Sleep(50 * time.Millisecond)
+
+	src.SetMonitorFreq(0)
+	writeTestFile(t, filespec, "prop1=2\n")
+	select {
+	case chg := <-changed:
+		t.Fatalf("change pushed while paused: %v", chg)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the change made while paused is picked up on resume without further events
+	src.SetMonitorFreq(time.Hour)
+	select {
+	case chg := <-changed:
+		if chg.NewVal != "2" {
+			t.Errorf("Watch; expected prop1=2, got %v", chg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("change made while paused not picked up after resuming")
+	}
+}
+
+// lateNotifier is a `SourceNotifier` that changes as watching starts and
+// never sends notifications, simulating a change made after the initial load
+// but before the watch is set up.
+type lateNotifier struct {
+	*SrcMap
+}
+
+func (ln lateNotifier) Notify(done <-chan struct{}) (<-chan struct{}, error) {
+	ln.Put("prop1", "2")
+	return make(chan struct{}), nil
+}
+
+func TestConfig_MonitorNotifyInitialCheck(t *testing.T) {
+	src := lateNotifier{SrcMap: NewSrcMapFromMap(map[string]string{"prop1": "1"})}
+	// polling would never detect the change within the test.
+	src.SetMonitorFreq(time.Hour)
+
+	config := &Config{}
+	defer config.Shutdown()
+	changed := make(chan PropChange, 10)
+	config.Watch("prop1", func(chg PropChange) { changed <- chg })
+	config.AppendSource(src)
+
+	select {
+	case chg := <-changed:
+		if chg.NewVal != "1" {
+			t.Fatalf("Watch; expected prop1=1, got %v", chg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("source not loaded")
+	}
+
+	select {
+	case chg := <-changed:
+		if chg.NewVal != "2" {
+			t.Errorf("Watch; expected prop1=2, got %v", chg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("change made before watching started was not picked up")
+	}
+}
+
+type NotifyErrors struct {
+	mutex sync.Mutex
+	errs  []error
+}
+
+func (n *NotifyErrors) ConfigChanged(cfg *Config, src SourceMonitored) {
+}
+
+func (n *NotifyErrors) ConfigError(cfg *Config, src Source, err error) {
+	n.mutex.Lock()
+	n.errs = append(n.errs, err)
+	n.mutex.Unlock()
+}
+
+func (n *NotifyErrors) count() int {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return len(n.errs)
+}
+
+func TestConfig_MonitorParseErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.conf")
+	writeTestFile(t, filespec, "name=one\n")
+
+	src, err := NewSrcFileFromFilespec(filespec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.SetMonitorFreq(10 * time.Millisecond)
+
+	config := &Config{}
+	defer config.Shutdown()
+	notify := &NotifyErrors{}
+	config.AddChangedListener(notify)
+	config.AppendSource(src)
+
+	// give the monitor time to start watching
+	time.Sleep(50 * time.Millisecond)
+
+	// keep-last-good retains the previous value and reports the error
+	writeTestFile(t, filespec, "name=two\nbroken\n")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filespec, future, future); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := notify.count(); n != 1 {
+		t.Errorf("ErrorListener was called %d times; expected 1", n)
+	}
+	if val, _ := config.String("name", ""); val != "one" {
+		t.Errorf("expected name=one, got %s", val)
+	}
+
+	// lenient uses the partial props and reports the error
+	src.SetParseMode(ini.ParseLenient)
+	writeTestFile(t, filespec, "name=three\nbroken\n")
+	future = future.Add(time.Hour)
+	if err := os.Chtimes(filespec, future, future); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := notify.count(); n != 2 {
+		t.Errorf("ErrorListener was called %d times; expected 2", n)
+	}
+	if val, _ := config.String("name", ""); val != "three" {
+		t.Errorf("expected name=three, got %s", val)
+	}
+}
+
+func TestConfig_Close(t *testing.T) {
+	config := &Config{}
+	config.AppendSource(makeSrc(5 * time.Millisecond))
+
+	config.Shutdown()
+	config.Shutdown() // must not panic
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := config.Close(ctx); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+
+	// sources added after shutdown are not monitored
+	mapSrc := makeSrc(5 * time.Millisecond)
+	config.AppendSource(mapSrc)
+	notify := &NotifyProps{}
+	config.AddChangedListener(notify)
+	mapSrc.Put("prop9", "added")
+	time.Sleep(50 * time.Millisecond)
+	if changes := notify.getChanges(); len(changes) != 0 {
+		t.Errorf("expected no changes after shutdown, got %v", changes)
+	}
+
+	// restart monitors all sources, including those added after shutdown
+	if err := config.RestartMonitoring(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if val, _ := config.String("prop9", ""); val != "added" {
+		t.Errorf("expected prop9=added after restart, got %s", val)
+	}
+	if err := config.Close(ctx); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestConfig_NewConfigWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	config := NewConfigWithContext(ctx)
+	mapSrc := makeSrc(5 * time.Millisecond)
+	config.AppendSource(mapSrc)
+
+	cancel()
+	time.Sleep(20 * time.Millisecond)
+
+	mapSrc.Put("prop1", "changed")
+	time.Sleep(50 * time.Millisecond)
+	if val, _ := config.String("prop1", ""); val != "1" {
+		t.Errorf("expected prop1=1 after context cancelled, got %s", val)
+	}
+	if err := config.RestartMonitoring(); err != context.Canceled {
+		t.Errorf("RestartMonitoring() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -2,6 +2,7 @@ package cfg
 
 import (
 	"math"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -279,6 +280,29 @@ func TestConfig_String(t *testing.T) {
 	runTest(tests, t)
 }
 
+func TestConfig_PreserveSpace(t *testing.T) {
+	config := &Config{}
+	config.AppendSource(NewSrcMapFromMap(map[string]string{"prop1": "  padded ", "prop2": "plain"}))
+
+	if val, _ := config.String("prop1", ""); val != "padded" {
+		t.Errorf("expected %q, got %q", "padded", val)
+	}
+	notify := &NotifyProps{}
+	config.AddChangedListener(notify)
+
+	config.SetWantPreserveSpace(true)
+	if !config.ShouldPreserveSpace() {
+		t.Error("ShouldPreserveSpace() expected true")
+	}
+	if val, _ := config.String("prop1", ""); val != "  padded " {
+		t.Errorf("expected %q, got %q", "  padded ", val)
+	}
+	want := []PropChange{{Name: "prop1", Type: PropModified, OldVal: "padded", NewVal: "  padded "}}
+	if got := notify.getChanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes after SetWantPreserveSpace = %v, want %v", got, want)
+	}
+}
+
 func TestConfig_Int(t *testing.T) {
 	tests := []test{
 		// srcLevel, propName, propVal, defVal, expectedVal, expectedErrText
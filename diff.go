@@ -0,0 +1,32 @@
+package cfg
+
+import (
+	"sort"
+)
+
+// diffProps compares two property maps and returns the list of
+// properties that were added, removed or modified, sorted by name.
+// Either map may be nil.
+func diffProps(old map[string]string, new map[string]string) []PropChange {
+	changes := make([]PropChange, 0)
+
+	for k, oldVal := range old {
+		newVal, ok := new[k]
+		if !ok {
+			changes = append(changes, PropChange{Name: k, Type: PropRemoved, OldVal: oldVal})
+		} else if newVal != oldVal {
+			changes = append(changes, PropChange{Name: k, Type: PropModified, OldVal: oldVal, NewVal: newVal})
+		}
+	}
+
+	for k, newVal := range new {
+		if _, ok := old[k]; !ok {
+			changes = append(changes, PropChange{Name: k, Type: PropAdded, NewVal: newVal})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
@@ -0,0 +1,36 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_diffProps(t *testing.T) {
+	tests := []struct {
+		name string
+		old  map[string]string
+		new  map[string]string
+		want []PropChange
+	}{
+		{"empty", nil, nil, []PropChange{}},
+		{"same", map[string]string{"a": "1"}, map[string]string{"a": "1"}, []PropChange{}},
+		{"added", nil, map[string]string{"a": "1"}, []PropChange{{Name: "a", Type: PropAdded, NewVal: "1"}}},
+		{"removed", map[string]string{"a": "1"}, map[string]string{}, []PropChange{{Name: "a", Type: PropRemoved, OldVal: "1"}}},
+		{"mixed",
+			map[string]string{"c": "1", "b": "2", "a": "3"},
+			map[string]string{"d": "4", "b": "two", "a": "3"},
+			[]PropChange{
+				{Name: "b", Type: PropModified, OldVal: "2", NewVal: "two"},
+				{Name: "c", Type: PropRemoved, OldVal: "1"},
+				{Name: "d", Type: PropAdded, NewVal: "4"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffProps(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffProps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package cfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wiggin77/cfg/ini"
+	"github.com/wiggin77/merror"
+)
+
+// DumpFormat is the format used by `Config.Dump`.
+type DumpFormat int
+
+const (
+	// DumpINI writes properties in INI format, with each name split into
+	// section and key at the last '.'. Names that cannot be split into a valid
+	// section and key are written outside any section. Properties whose name
+	// cannot be written as a key, such as `include` which would be read back as
+	// an include directive, are written as a comment instead, and `Dump`
+	// returns an error listing them after writing everything else.
+	DumpINI DumpFormat = iota
+
+	// DumpJSON writes properties as a JSON object mapping names to values,
+	// or to objects containing the value and source when annotated.
+	DumpJSON
+
+	// DumpEnv writes properties as an environment file containing `NAME=value`
+	// lines, with names mapped as the reverse of `SrcEnv` using the
+	// `DefaultEnvSeparator`, e.g. `db.host` becomes `DB__HOST`.
+	DumpEnv
+)
+
+// String returns a string representation of the `DumpFormat`.
+func (format DumpFormat) String() string {
+	switch format {
+	case DumpINI:
+		return "ini"
+	case DumpJSON:
+		return "json"
+	case DumpEnv:
+		return "env"
+	}
+	return "unknown"
+}
+
+// dumpProp is a property written by `Config.Dump`.
+type dumpProp struct {
+	Value   string `json:"value"`
+	Source  string `json:"source"`
+	name    string
+	invalid bool // name cannot be written in INI format
+}
+
+// Dump writes the effective value of every property, resolved through all
+// sources and interpolated if enabled, to `w` in the specified format, sorted
+// by name. If `annotate` is true then each property is annotated with the
+// source that supplied it.
+func (config *Config) Dump(w io.Writer, format DumpFormat, annotate bool) error {
+	props := config.dumpProps()
+
+	var err error
+	switch format {
+	case DumpINI:
+		err = dumpINI(w, props, annotate)
+	case DumpJSON:
+		err = dumpJSON(w, props, annotate)
+	case DumpEnv:
+		err = dumpEnv(w, props, annotate)
+	default:
+		err = fmt.Errorf("unsupported dump format %d", format)
+	}
+	return err
+}
+
+// dumpProps returns the effective properties sorted by name, along with
+// the source that supplied each value.
+func (config *Config) dumpProps() []dumpProp {
+	config.mutexSrc.RLock()
+	defer config.mutexSrc.RUnlock()
+
+	snap := config.snapshotLocked()
+	props := make([]dumpProp, 0, len(snap.props))
+	for name := range snap.props {
+		val, _, _ := snap.value(name)
+		prop := dumpProp{name: name, Value: val}
+		if info, err := config.lookup(name); err != ErrNotFound {
+			prop.Source = describeSource(info.PropSource)
+		}
+		props = append(props, prop)
+	}
+	sort.Slice(props, func(i, j int) bool { return props[i].name < props[j].name })
+	return props
+}
+
+// describeSource returns a description of the source supplying a value,
+// including its name and position within the list of sources.
+func describeSource(ps PropSource) string {
+	desc := fmt.Sprintf("%T", ps.Source)
+	if s, ok := ps.Source.(fmt.Stringer); ok {
+		desc = s.String()
+	}
+	if ps.SourceName != "" {
+		return fmt.Sprintf("source %d '%s' (%s)", ps.Index, ps.SourceName, desc)
+	}
+	return fmt.Sprintf("source %d (%s)", ps.Index, desc)
+}
+
+func dumpINI(w io.Writer, props []dumpProp, annotate bool) error {
+	merr := merror.New()
+
+	// group by section, with properties outside a section first.
+	sections := make(map[string][]dumpProp)
+	for _, prop := range props {
+		section, key, ok := iniName(prop.name)
+		if !ok {
+			merr.Append(fmt.Errorf("property %s cannot be written in INI format", strconv.Quote(prop.name)))
+			prop.invalid = true
+			key = prop.name
+		}
+		prop.name = key
+		sections[section] = append(sections[section], prop)
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb := &strings.Builder{}
+	for _, name := range names {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		if name != "" {
+			fmt.Fprintf(sb, "[%s]\n", name)
+		}
+		for _, prop := range sections[name] {
+			if annotate {
+				fmt.Fprintf(sb, "; %s\n", prop.Source)
+			}
+			if prop.invalid {
+				fmt.Fprintf(sb, "; skipped property %s\n", strconv.Quote(prop.name))
+				continue
+			}
+			fmt.Fprintf(sb, "%s = %s\n", prop.name, ini.QuoteValue(prop.Value))
+		}
+	}
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return err
+	}
+	return merr.ErrorOrNil()
+}
+
+// iniName splits a property name into INI section and key at the last '.',
+// or returns the whole name as the key if that would not read back as the
+// same name. Returns `ok=false` if the name cannot be written as a key.
+func iniName(name string) (section string, key string, ok bool) {
+	if i := strings.LastIndex(name, "."); i != -1 {
+		section, key = name[:i], name[i+1:]
+		if ini.ValidSectionName(section) && ini.ValidKey(key) {
+			return section, key, true
+		}
+	}
+	return "", name, ini.ValidKey(name)
+}
+
+func dumpJSON(w io.Writer, props []dumpProp, annotate bool) error {
+	var v interface{}
+	if annotate {
+		m := make(map[string]dumpProp, len(props))
+		for _, prop := range props {
+			m[prop.name] = prop
+		}
+		v = m
+	} else {
+		m := make(map[string]string, len(props))
+		for _, prop := range props {
+			m[prop.name] = prop.Value
+		}
+		v = m
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func dumpEnv(w io.Writer, props []dumpProp, annotate bool) error {
+	sb := &strings.Builder{}
+	for _, prop := range props {
+		if annotate {
+			fmt.Fprintf(sb, "# %s\n", prop.Source)
+		}
+		fmt.Fprintf(sb, "%s=%s\n", envName(prop.name), envValue(prop.Value))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// envName maps a property name to an environment variable name, being the
+// reverse of `SrcEnv` with the default separator. Characters not valid in
+// environment variable names are replaced with '_'.
+func envName(name string) string {
+	name = strings.Replace(name, ".", DefaultEnvSeparator, -1)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// envValue returns the value double-quoted and escaped if it contains
+// characters with special meaning in environment files.
+func envValue(val string) string {
+	for _, r := range val {
+		if !strconv.IsPrint(r) || strings.ContainsRune(" \t\"'`$\\#;&|<>()", r) {
+			return strconv.Quote(val)
+		}
+	}
+	return val
+}
@@ -0,0 +1,116 @@
+package cfg
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/wiggin77/cfg/ini"
+)
+
+func TestConfig_Dump(t *testing.T) {
+	config := &Config{}
+	config.AppendSource(NewSrcMapFromMap(map[string]string{
+		"name":    "app",
+		"db.host": "localhost",
+	}))
+	config.AppendSource(NewSrcMapFromMap(map[string]string{
+		"db.host":       "shadowed",
+		"db.pool.size":  "10",
+		"greeting":      "hello world",
+		"server.banner": "#1 server",
+	}))
+
+	tests := []struct {
+		format   DumpFormat
+		annotate bool
+		want     string
+	}{
+		{DumpINI, false, "greeting = hello world\nname = app\n\n[db]\nhost = localhost\n\n[db.pool]\nsize = 10\n\n[server]\nbanner = \"#1 server\"\n"},
+		{DumpINI, true, "; source 1 (*cfg.SrcMap)\ngreeting = hello world\n; source 0 (*cfg.SrcMap)\nname = app\n\n" +
+			"[db]\n; source 0 (*cfg.SrcMap)\nhost = localhost\n\n[db.pool]\n; source 1 (*cfg.SrcMap)\nsize = 10\n\n" +
+			"[server]\n; source 1 (*cfg.SrcMap)\nbanner = \"#1 server\"\n"},
+		{DumpJSON, false, "{\n  \"db.host\": \"localhost\",\n  \"db.pool.size\": \"10\",\n  \"greeting\": \"hello world\",\n" +
+			"  \"name\": \"app\",\n  \"server.banner\": \"#1 server\"\n}\n"},
+		{DumpEnv, false, "DB__HOST=localhost\nDB__POOL__SIZE=10\nGREETING=\"hello world\"\nNAME=app\nSERVER__BANNER=\"#1 server\"\n"},
+		{DumpEnv, true, "# source 0 (*cfg.SrcMap)\nDB__HOST=localhost\n# source 1 (*cfg.SrcMap)\nDB__POOL__SIZE=10\n" +
+			"# source 1 (*cfg.SrcMap)\nGREETING=\"hello world\"\n# source 0 (*cfg.SrcMap)\nNAME=app\n" +
+			"# source 1 (*cfg.SrcMap)\nSERVER__BANNER=\"#1 server\"\n"},
+	}
+
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		if err := config.Dump(buf, tt.format, tt.annotate); err != nil {
+			t.Errorf("Dump(%s, %t) error: %v", tt.format, tt.annotate, err)
+			continue
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("Dump(%s, %t) = %q, want %q", tt.format, tt.annotate, got, tt.want)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := config.Dump(buf, DumpJSON, true); err != nil {
+		t.Fatal(err)
+	}
+	want := "\"db.host\": {\n    \"value\": \"localhost\",\n    \"source\": \"source 0 (*cfg.SrcMap)\"\n  }"
+	if !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("Dump(json, true) = %s, want to contain %s", buf.String(), want)
+	}
+
+	if err := config.Dump(buf, DumpFormat(99), false); err == nil {
+		t.Error("Dump with unsupported format expected error")
+	}
+}
+
+func TestConfig_DumpINIRoundTrip(t *testing.T) {
+	props := map[string]string{
+		"name":         "app",
+		"db.host":      " localhost ",
+		"db.include":   "foo.ini",
+		"db.#comment":  "1",
+		"db.[sec]":     "2",
+		".leading":     "3",
+		"trailing.":    "4",
+		"db.pool.size": "10",
+		"multi":        "line1\nline2",
+	}
+	config := &Config{}
+	config.SetWantPreserveSpace(true)
+	config.AppendSource(NewSrcMapFromMap(props))
+
+	buf := &bytes.Buffer{}
+	if err := config.Dump(buf, DumpINI, true); err != nil {
+		t.Fatal(err)
+	}
+	i := ini.Ini{}
+	if err := i.LoadFromString(buf.String()); err != nil {
+		t.Fatalf("loading dump failed: %v\n%s", err, buf.String())
+	}
+	if got := i.ToMap(); !reflect.DeepEqual(got, props) {
+		t.Errorf("loaded dump = %q, want %q", got, props)
+	}
+
+	for _, name := range []string{"include", "!include", ";x", "[x]", "a=", "a\nb"} {
+		config := &Config{}
+		config.AppendSource(NewSrcMapFromMap(map[string]string{name: "1", "db.host": "localhost"}))
+		buf := &bytes.Buffer{}
+		if err := config.Dump(buf, DumpINI, false); err == nil || !strings.Contains(err.Error(), strconv.Quote(name)) {
+			t.Errorf("Dump(%q) expected error naming the property, got %v", name, err)
+		}
+
+		// everything else is still written, and the dump still loads.
+		i := ini.Ini{}
+		if err := i.LoadFromString(buf.String()); err != nil {
+			t.Fatalf("loading dump failed: %v\n%s", err, buf.String())
+		}
+		if got, want := i.ToMap(), map[string]string{"db.host": "localhost"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("loaded dump of %q = %q, want %q", name, got, want)
+		}
+		if !strings.Contains(buf.String(), "; skipped property "+strconv.Quote(name)) {
+			t.Errorf("Dump(%q) expected skipped property comment, got %q", name, buf.String())
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package cfg
+
+import (
+	"fmt"
+	"time"
+)
+
+// Op describes the operation that failed.
+type Op string
+
+const (
+	// OpGetProps indicates `Source.GetProps` returned an error.
+	OpGetProps Op = "GetProps"
+	// OpGetLastModified indicates `SourceMonitored.GetLastModified` returned an error.
+	OpGetLastModified Op = "GetLastModified"
+	// OpListener indicates a listener panicked while being notified of changes
+	// to the source.
+	OpListener Op = "listener"
+)
+
+// SourceError describes an error involving a config source. Errors are
+// reported to listeners implementing `ErrorListener`.
+type SourceError struct {
+	// Source is the source involved.
+	Source Source
+	// Name is the name of the source, or empty if unnamed.
+	Name string
+	// Op is the operation that failed.
+	Op Op
+	// Err is the underlying error.
+	Err error
+	// Time is when the error occurred.
+	Time time.Time
+}
+
+// Error returns a string representation of the `SourceError`.
+func (se *SourceError) Error() string {
+	return fmt.Sprintf("%s failed for source %s: %v", se.Op, sourceDesc(se.Name, se.Source), se.Err)
+}
+
+// Unwrap returns the underlying error.
+func (se *SourceError) Unwrap() error {
+	return se.Err
+}
+
+// PartialLoadError is returned by `Source.GetProps`, along with the properties
+// to use, when the source could not be fully loaded but its properties should
+// still replace those previously fetched, such as `SrcFile` in `ini.ParseStrict`
+// or `ini.ParseLenient` mode. Any other error causes `Config` to retain the
+// previously fetched properties.
+type PartialLoadError struct {
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns a string representation of the `PartialLoadError`.
+func (pe *PartialLoadError) Error() string {
+	return pe.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (pe *PartialLoadError) Unwrap() error {
+	return pe.Err
+}
+
+// SourceHealth describes the health of a config source.
+type SourceHealth struct {
+	// Name is the name of the source, or empty if unnamed.
+	Name   string
+	Source Source
+	// LastLoaded is when properties were last fetched from the source without error.
+	LastLoaded time.Time
+	// LastError is the most recent error involving the source, or nil if none.
+	LastError *SourceError
+	// ConsecutiveErrors is the number of errors since the source last
+	// succeeded.
+	ConsecutiveErrors int
+}
+
+// Healthy returns true if the most recent operation on the source succeeded.
+func (sh SourceHealth) Healthy() bool {
+	return sh.ConsecutiveErrors == 0
+}
+
+// GetSourceHealth returns the health of each source, in the order they
+// are checked when resolving a property value.
+func (config *Config) GetSourceHealth() []SourceHealth {
+	config.mutexSrc.RLock()
+	defer config.mutexSrc.RUnlock()
+
+	arr := make([]SourceHealth, 0, len(config.srcs))
+	for _, se := range config.srcs {
+		arr = append(arr, SourceHealth{
+			Name:              se.name,
+			Source:            se.src,
+			LastLoaded:        se.lastLoaded,
+			LastError:         se.lastErr,
+			ConsecutiveErrors: se.errCount,
+		})
+	}
+	return arr
+}
+
+// sourceDesc returns a description of a source for use in messages.
+func sourceDesc(name string, src Source) string {
+	desc := fmt.Sprintf("%T", src)
+	if name != "" {
+		desc = fmt.Sprintf("'%s' (%s)", name, desc)
+	}
+	return desc
+}
+
+// String returns a description of the source for use in messages.
+func (se *sourceEntry) String() string {
+	return sourceDesc(se.name, se.src)
+}
+
+// newSourceError creates a `SourceError` for the source.
+func (se *sourceEntry) newSourceError(op Op, err error) *SourceError {
+	return &SourceError{Source: se.src, Name: se.name, Op: op, Err: err, Time: time.Now()}
+}
+
+// failed records an error for the source and returns it.
+//
+// Must be called with `mutexSrc` locked.
+func (se *sourceEntry) failed(op Op, err error) *SourceError {
+	serr := se.newSourceError(op, err)
+	se.lastErr = serr
+	se.errCount++
+	return serr
+}
+
+// sourceFailed records an error for the source and returns it.
+func (config *Config) sourceFailed(se *sourceEntry, op Op, err error) *SourceError {
+	config.mutexSrc.Lock()
+	defer config.mutexSrc.Unlock()
+	return se.failed(op, err)
+}
+
+// sourceRecovered resets the error count for the source if the most
+// recent error was from `op`.
+func (config *Config) sourceRecovered(se *sourceEntry, op Op) {
+	config.mutexSrc.Lock()
+	defer config.mutexSrc.Unlock()
+	if se.errCount > 0 && se.lastErr != nil && se.lastErr.Op == op {
+		se.errCount = 0
+	}
+}
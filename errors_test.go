@@ -0,0 +1,209 @@
+package cfg
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingSrc is a monitored source whose operations fail on demand.
+type failingSrc struct {
+	AbstractSourceMonitor
+	mutex    sync.Mutex
+	props    map[string]string
+	errProps map[string]string // returned along with errGet
+	errGet   error
+	errLM    error
+	lm       time.Time
+}
+
+func (fs *failingSrc) GetProps() (map[string]string, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if fs.errGet != nil {
+		return fs.errProps, fs.errGet
+	}
+	return fs.props, nil
+}
+
+func (fs *failingSrc) GetLastModified() (time.Time, error) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	return fs.lm, fs.errLM
+}
+
+func (fs *failingSrc) set(errGet error, errLM error) {
+	fs.mutex.Lock()
+	fs.errGet = errGet
+	fs.errLM = errLM
+	fs.lm = time.Now()
+	fs.mutex.Unlock()
+}
+
+func TestConfig_SourceErrors(t *testing.T) {
+	src := &failingSrc{props: map[string]string{"prop1": "1"}}
+	src.SetMonitorFreq(5 * time.Millisecond)
+
+	config := &Config{}
+	defer config.Shutdown()
+	notify := &NotifyErrors{}
+	config.AddChangedListener(notify)
+	if err := config.AppendNamedSource("flaky", src); err != nil {
+		t.Fatal(err)
+	}
+	if h := config.GetSourceHealth()[0]; !h.Healthy() || h.LastLoaded.IsZero() || h.Name != "flaky" {
+		t.Errorf("expected healthy source, got %+v", h)
+	}
+
+	errGet := errors.New("db down")
+	src.set(errGet, nil)
+	time.Sleep(50 * time.Millisecond)
+
+	h := config.GetSourceHealth()[0]
+	if h.Healthy() || h.LastError == nil {
+		t.Fatalf("expected unhealthy source, got %+v", h)
+	}
+	if h.LastError.Op != OpGetProps || h.LastError.Err != errGet || h.LastError.Source != src || h.LastError.Time.IsZero() {
+		t.Errorf("unexpected LastError %+v", h.LastError)
+	}
+	if s := h.LastError.Error(); s != "GetProps failed for source 'flaky' (*cfg.failingSrc): db down" {
+		t.Errorf("SourceError.Error() = %s", s)
+	}
+	if val, _ := config.String("prop1", ""); val != "1" {
+		t.Errorf("expected prop1=1 retained, got %s", val)
+	}
+
+	errLM := errors.New("stat failed")
+	src.set(nil, errLM)
+	time.Sleep(50 * time.Millisecond)
+	if h := config.GetSourceHealth()[0]; h.LastError.Op != OpGetLastModified || h.LastError.Err != errLM {
+		t.Errorf("unexpected LastError %+v", h.LastError)
+	}
+
+	src.set(nil, nil)
+	time.Sleep(50 * time.Millisecond)
+	if h := config.GetSourceHealth()[0]; !h.Healthy() {
+		t.Errorf("expected source to recover, got %+v", h)
+	}
+
+	notify.mutex.Lock()
+	defer notify.mutex.Unlock()
+	var ops []Op
+	for _, err := range notify.errs {
+		serr, ok := err.(*SourceError)
+		if !ok {
+			t.Fatalf("expected *SourceError, got %T", err)
+		}
+		if len(ops) == 0 || ops[len(ops)-1] != serr.Op {
+			ops = append(ops, serr.Op)
+		}
+	}
+	if len(ops) != 2 || ops[0] != OpGetProps || ops[1] != OpGetLastModified {
+		t.Errorf("expected GetProps then GetLastModified errors, got %v", ops)
+	}
+}
+
+func TestConfig_PartialLoad(t *testing.T) {
+	src := &failingSrc{props: map[string]string{"prop1": "1"}, errProps: map[string]string{}}
+	src.SetMonitorFreq(5 * time.Millisecond)
+
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(src)
+
+	// errors keep the previous props, even when a map is returned.
+	errGet := errors.New("db down")
+	src.set(errGet, nil)
+	time.Sleep(50 * time.Millisecond)
+	if val, _ := config.String("prop1", ""); val != "1" {
+		t.Errorf("expected prop1=1 retained, got %s", val)
+	}
+
+	// partial loads replace the props.
+	src.mutex.Lock()
+	src.errProps = map[string]string{"prop2": "2"}
+	src.mutex.Unlock()
+	src.set(&PartialLoadError{Err: errGet}, nil)
+	time.Sleep(50 * time.Millisecond)
+	if _, err := config.String("prop1", ""); err != ErrNotFound {
+		t.Errorf("expected prop1 removed, got err=%v", err)
+	}
+	if val, _ := config.String("prop2", ""); val != "2" {
+		t.Errorf("expected prop2=2, got %s", val)
+	}
+	if h := config.GetSourceHealth()[0]; h.Healthy() || h.LastError.Err != errGet {
+		t.Errorf("expected unhealthy source with underlying error, got %+v", h)
+	}
+}
+
+func TestConfig_PanicOnError(t *testing.T) {
+	src := &failingSrc{props: map[string]string{"prop1": "1"}}
+	src.SetMonitorFreq(5 * time.Millisecond)
+
+	config := &Config{}
+	defer config.Shutdown()
+	config.SetWantPanicOnError(true)
+	notify := &NotifyErrors{}
+	config.AddChangedListener(notify)
+	config.AppendSource(src)
+
+	// errors while monitoring are reported rather than panicking.
+	src.set(errors.New("db down"), errors.New("stat failed"))
+	time.Sleep(50 * time.Millisecond)
+	src.set(errors.New("db down"), nil)
+	time.Sleep(50 * time.Millisecond)
+	if notify.count() == 0 {
+		t.Error("expected errors while monitoring to be reported")
+	}
+
+	// errors adding a source panic on the caller's goroutine.
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("AppendSource; expected panic")
+			}
+		}()
+		config.AppendSource(&failingSrc{errGet: errors.New("unavailable")})
+	}()
+
+	// the config remains usable after the panic.
+	if val, _ := config.String("prop1", ""); val != "1" {
+		t.Errorf("expected prop1=1, got %s", val)
+	}
+}
+
+type panicListener struct{}
+
+func (pl panicListener) PropsChanged(cfg *Config, src Source, changes []PropChange) {
+	panic("boom")
+}
+
+func (pl panicListener) ConfigChanged(cfg *Config, src SourceMonitored) {
+}
+
+func TestConfig_ListenerPanic(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+
+	notify := &NotifyErrors{}
+	props := &NotifyProps{}
+	config.AddChangedListener(panicListener{})
+	config.AddChangedListener(props)
+	config.AddChangedListener(notify)
+	if err := config.AppendNamedSource("map", NewSrcMapFromMap(map[string]string{"prop1": "1"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(props.getChanges()) != 1 {
+		t.Error("listener after panicking listener was not called")
+	}
+	if notify.count() != 1 {
+		t.Fatalf("ErrorListener was called %d times; expected 1", notify.count())
+	}
+	serr := notify.errs[0].(*SourceError)
+	if serr.Op != OpListener || serr.Name != "map" || !strings.Contains(serr.Err.Error(), "boom") {
+		t.Errorf("unexpected SourceError %v", serr)
+	}
+}
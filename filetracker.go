@@ -0,0 +1,435 @@
+package cfg
+
+import (
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ChangeDetection determines how file based sources detect changes.
+type ChangeDetection int
+
+const (
+	// DetectModTime detects changes by comparing file modification time,
+	// size and identity. This is the default.
+	DetectModTime ChangeDetection = iota
+
+	// DetectContentHash detects changes by hashing the file contents, meaning
+	// edits within the file system's timestamp granularity are detected and
+	// touching a file without changing it is ignored. The file is read each
+	// time the source is checked for changes.
+	DetectContentHash
+)
+
+// fileTracker detects changes to a file and reads its contents.
+//
+// When tracking by filespec the file is re-opened for each read, and a change
+// is detected when the file at that path is modified or replaced by a different
+// file, such as when a new file is renamed over the old one or a symlink is
+// changed to point elsewhere. If the file temporarily disappears no change is
+// reported and the last known state is retained.
+//
+// When tracking an already open `os.File` a change is detected when the file
+// is modified.
+type fileTracker struct {
+	mutex    sync.Mutex
+	filespec string
+	file     *os.File
+	detect   ChangeDetection
+	fi       os.FileInfo       // last seen
+	sum      [sha256.Size]byte // last seen, when detecting via hash
+	hashed   bool
+	lm       time.Time // time of last detected change
+}
+
+// newFileTrackerFromFilespec creates a `fileTracker` for the specified path.
+func newFileTrackerFromFilespec(filespec string) *fileTracker {
+	return &fileTracker{filespec: filespec}
+}
+
+// newFileTracker creates a `fileTracker` for an open file.
+func newFileTracker(file *os.File) *fileTracker {
+	return &fileTracker{file: file}
+}
+
+// name returns the path of the tracked file.
+func (ft *fileTracker) name() string {
+	if ft.file != nil {
+		return ft.file.Name()
+	}
+	return ft.filespec
+}
+
+// setDetection sets the change detection strategy.
+func (ft *fileTracker) setDetection(detect ChangeDetection) {
+	ft.mutex.Lock()
+	ft.detect = detect
+	ft.hashed = false
+	ft.mutex.Unlock()
+}
+
+// lastModified checks the file for changes and returns the time the
+// latest change was detected.
+func (ft *fileTracker) lastModified() (time.Time, error) {
+	ft.mutex.Lock()
+	detect := ft.detect
+	ft.mutex.Unlock()
+
+	if detect == DetectContentHash {
+		_, lm, err := ft.read()
+		if err != nil && ft.isTemporarilyMissing(err) {
+			return ft.getLastModified(), nil
+		}
+		return lm, err
+	}
+
+	var fi os.FileInfo
+	var err error
+	if ft.file != nil {
+		fi, err = ft.file.Stat()
+	} else {
+		fi, err = os.Stat(ft.filespec)
+	}
+
+	if err != nil {
+		if ft.isTemporarilyMissing(err) {
+			return ft.getLastModified(), nil
+		}
+		return time.Now(), err
+	}
+
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	ft.update(fi)
+	return ft.lm, nil
+}
+
+// isTemporarilyMissing returns true if the error indicates a file tracked
+// by filespec is missing after having previously existed, such as part way
+// through being replaced.
+func (ft *fileTracker) isTemporarilyMissing(err error) bool {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	return os.IsNotExist(err) && ft.file == nil && !ft.lm.IsZero()
+}
+
+// getLastModified returns the time the latest change was detected
+// without checking the file.
+func (ft *fileTracker) getLastModified() time.Time {
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	return ft.lm
+}
+
+// read returns the entire contents of the file, plus the time the latest
+// change was detected, which accounts for the file just read.
+func (ft *fileTracker) read() ([]byte, time.Time, error) {
+	file := ft.file
+	if file == nil {
+		f, err := os.Open(ft.filespec)
+		if err != nil {
+			return nil, time.Now(), err
+		}
+		defer f.Close()
+		file = f
+	} else if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, time.Now(), err
+	}
+
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, time.Now(), err
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, time.Now(), err
+	}
+
+	ft.mutex.Lock()
+	defer ft.mutex.Unlock()
+	if ft.detect == DetectContentHash {
+		ft.updateHash(data)
+	} else {
+		ft.update(fi)
+	}
+	return data, ft.lm, nil
+}
+
+// update records the latest file info, updating the last modified time if
+// the file was changed or replaced.
+//
+// Must be called with `mutex` locked.
+func (ft *fileTracker) update(fi os.FileInfo) {
+	prev := ft.fi
+	ft.fi = fi
+	if prev != nil && os.SameFile(prev, fi) && prev.ModTime().Equal(fi.ModTime()) && prev.Size() == fi.Size() {
+		return
+	}
+
+	// Use the file's modification time unless it would move backwards,
+	// which can happen when a file is replaced by an older one.
+	if fi.ModTime().After(ft.lm) {
+		ft.lm = fi.ModTime()
+	} else {
+		ft.touch()
+	}
+}
+
+// updateHash records the hash of the latest file contents, updating the
+// last modified time if the contents changed.
+//
+// Must be called with `mutex` locked.
+func (ft *fileTracker) updateHash(data []byte) {
+	sum := sha256.Sum256(data)
+	if ft.hashed && sum == ft.sum {
+		return
+	}
+	ft.sum = sum
+	ft.hashed = true
+	ft.touch()
+}
+
+// touch sets the last modified time to now, ensuring it always
+// moves forward.
+//
+// Must be called with `mutex` locked.
+func (ft *fileTracker) touch() {
+	ft.lm = advance(ft.lm)
+}
+
+// advance returns the current time, or `lm` plus a nanosecond if the current
+// time is not after `lm`, such as when the clock is coarse or goes backwards.
+// Used to ensure last modified times always move forward.
+func advance(lm time.Time) time.Time {
+	now := time.Now()
+	if !now.After(lm) {
+		now = lm.Add(time.Nanosecond)
+	}
+	return now
+}
+
+// notify watches the directory containing the file for changes.
+// See `SourceNotifier`.
+func (ft *fileTracker) notify(done <-chan struct{}) (<-chan struct{}, error) {
+	return notifyDirs([]string{filepath.Dir(ft.name())}, done)
+}
+
+// fileSet detects changes to a file plus the files it depends on, such as the
+// files it includes. A change to any file in the set advances the set's last
+// modified time, which always moves forward.
+type fileSet struct {
+	mutex   sync.Mutex
+	main    *fileTracker
+	deps    []*fileTracker
+	detect  ChangeDetection
+	stamps  []time.Time // last modified time of main followed by deps, when last checked
+	lm      time.Time
+	rewatch chan struct{} // closed when the directories to watch change
+}
+
+// newFileSet creates a `fileSet` for the file tracked by `main`, with no
+// dependencies.
+func newFileSet(main *fileTracker) *fileSet {
+	return &fileSet{main: main, rewatch: make(chan struct{})}
+}
+
+// setDeps sets the paths of the files the main file depends on. Existing
+// trackers are retained for paths already in the set.
+func (fs *fileSet) setDeps(paths []string) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	existing := make(map[string]*fileTracker, len(fs.deps))
+	for _, ft := range fs.deps {
+		existing[ft.filespec] = ft
+	}
+	dirs := fs.dirs()
+
+	deps := make([]*fileTracker, 0, len(paths))
+	for _, path := range paths {
+		ft, ok := existing[path]
+		if !ok {
+			ft = newFileTrackerFromFilespec(path)
+			ft.setDetection(fs.detect)
+		}
+		deps = append(deps, ft)
+	}
+	fs.deps = deps
+
+	if !sameStrings(dirs, fs.dirs()) {
+		close(fs.rewatch)
+		fs.rewatch = make(chan struct{})
+	}
+}
+
+// setDetection sets the change detection strategy for every file in the set.
+func (fs *fileSet) setDetection(detect ChangeDetection) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+
+	fs.detect = detect
+	fs.main.setDetection(detect)
+	for _, ft := range fs.deps {
+		ft.setDetection(detect)
+	}
+}
+
+// lastModified checks every file in the set for changes and returns the
+// time the latest change was detected. Errors checking dependencies, such
+// as a missing include, are ignored since they are reported when parsing.
+func (fs *fileSet) lastModified() (time.Time, error) {
+	lm, err := fs.main.lastModified()
+	if err != nil {
+		return lm, err
+	}
+
+	fs.mutex.Lock()
+	deps := fs.deps
+	fs.mutex.Unlock()
+
+	stamps := make([]time.Time, 0, len(deps)+1)
+	stamps = append(stamps, lm)
+	for _, ft := range deps {
+		dlm, err := ft.lastModified()
+		if err != nil {
+			dlm = ft.getLastModified()
+		}
+		stamps = append(stamps, dlm)
+	}
+
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	if !sameTimes(stamps, fs.stamps) {
+		fs.stamps = stamps
+		latest := time.Time{}
+		for _, t := range stamps {
+			if t.After(latest) {
+				latest = t
+			}
+		}
+		if latest.After(fs.lm) {
+			fs.lm = latest
+		} else {
+			fs.lm = fs.lm.Add(time.Nanosecond)
+		}
+	}
+	return fs.lm, nil
+}
+
+// dirs returns the existing directories containing the files in the set.
+//
+// Must be called with `mutex` locked.
+func (fs *fileSet) dirs() []string {
+	dirs := []string{filepath.Dir(fs.main.name())}
+	for _, ft := range fs.deps {
+		dir := filepath.Dir(ft.filespec)
+		if containsString(dirs, dir) {
+			continue
+		}
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// notify watches the directories containing the files in the set for
+// changes, watching again whenever the dependencies change directory.
+// See `SourceNotifier`.
+func (fs *fileSet) notify(done <-chan struct{}) (<-chan struct{}, error) {
+	return notifyRewatch(func() ([]string, <-chan struct{}) {
+		fs.mutex.Lock()
+		defer fs.mutex.Unlock()
+		return fs.dirs(), fs.rewatch
+	}, done)
+}
+
+// notifyRewatch watches the directories returned by `watch` for changes until
+// `done` is closed, calling `watch` again to get a new list of directories
+// whenever the channel it returned is closed. See `notifyDirs`.
+func notifyRewatch(watch func() ([]string, <-chan struct{}), done <-chan struct{}) (<-chan struct{}, error) {
+	dirs, rewatch := watch()
+	stop := make(chan struct{})
+	ch, err := notifyDirs(dirs, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan struct{}, 1)
+	send := func() {
+		select {
+		case events <- struct{}{}:
+		default:
+			// notification already pending
+		}
+	}
+
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case _, ok := <-ch:
+				if !ok {
+					close(stop)
+					return
+				}
+				send()
+			case <-rewatch:
+				close(stop)
+				dirs, rewatch = watch()
+				stop = make(chan struct{})
+				if ch, err = notifyDirs(dirs, stop); err != nil {
+					return // closing `events` falls back to polling
+				}
+				// changes may have been missed while not watching.
+				send()
+			case <-done:
+				close(stop)
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// containsString returns true if `arr` contains `s`.
+func containsString(arr []string, s string) bool {
+	for _, a := range arr {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sameStrings returns true if both slices contain the same strings in
+// the same order.
+func sameStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTimes returns true if both slices contain the same times in the
+// same order.
+func sameTimes(a []time.Time, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
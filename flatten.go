@@ -0,0 +1,51 @@
+package cfg
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// flatten converts nested maps and slices, such as those produced by decoding
+// JSON, YAML or TOML, into a flat map of property names to values. Nested maps are flattened
+// into dotted names the same way INI sections are, e.g. `{"db":{"host":"x"}}`
+// becomes `db.host=x`.
+//
+// Slices are flattened using the zero-based index of each element as the name,
+// e.g. `{"hosts":["a","b"]}` becomes `hosts.0=a` and `hosts.1=b`. Empty maps
+// and slices produce no properties, and null values produce an empty string.
+func flatten(prefix string, v interface{}, m map[string]string) {
+	join := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range val {
+			flatten(join(k), elem, m)
+		}
+	case map[interface{}]interface{}:
+		for k, elem := range val {
+			flatten(join(fmt.Sprint(k)), elem, m)
+		}
+	case []interface{}:
+		for i, elem := range val {
+			flatten(join(strconv.Itoa(i)), elem, m)
+		}
+	case []map[string]interface{}:
+		for i, elem := range val {
+			flatten(join(strconv.Itoa(i)), elem, m)
+		}
+	case nil:
+		m[prefix] = ""
+	case string:
+		m[prefix] = val
+	case time.Time:
+		m[prefix] = val.Format(time.RFC3339Nano)
+	default:
+		m[prefix] = fmt.Sprint(val)
+	}
+}
@@ -2,4 +2,8 @@ module github.com/wiggin77/cfg
 
 go 1.12
 
-require github.com/wiggin77/merror v1.0.2
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/wiggin77/merror v1.0.2
+	gopkg.in/yaml.v3 v3.0.1
+)
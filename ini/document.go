@@ -0,0 +1,487 @@
+package ini
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/wiggin77/merror"
+)
+
+// Document is an INI file loaded for editing. Unlike `Ini`, a `Document`
+// retains comments, blank lines, and the order of sections and keys, and is
+// written back byte-for-byte identical except for lines changed via `Set`,
+// `Delete` and `AddSection`.
+//
+// Include directives are retained as is and not expanded. A zero value
+// `Document` is empty and ready to use.
+type Document struct {
+	mutex   sync.RWMutex
+	entries []*docEntry
+	opts    parseOptions
+}
+
+type entryKind int
+
+const (
+	entryOther   entryKind = iota // comments, blank lines, includes and invalid lines
+	entrySection                  // section header
+	entryProp                     // key/value pair, possibly spanning multiple lines
+)
+
+// docEntry is one or more lines of a `Document`.
+type docEntry struct {
+	kind    entryKind
+	section string // name of the section containing the entry, or of the header
+	key     string
+	val     string
+	raw     string // original text, including line terminators
+}
+
+// SetWantJoinContinuations sets the flag determining if values may span
+// multiple lines using a trailing backslash or indented continuation lines.
+// See `Ini.SetWantJoinContinuations`. Defaults to false, and applies to
+// subsequent loads.
+func (doc *Document) SetWantJoinContinuations(b bool) {
+	doc.mutex.Lock()
+	doc.opts.joinContinuations = b
+	doc.mutex.Unlock()
+}
+
+// ShouldJoinContinuations gets the flag determining if values may span
+// multiple lines using a trailing backslash or indented continuation lines.
+func (doc *Document) ShouldJoinContinuations() (b bool) {
+	doc.mutex.RLock()
+	b = doc.opts.joinContinuations
+	doc.mutex.RUnlock()
+	return
+}
+
+// SetWantStripInlineComments sets the flag determining if comments following
+// a value on the same line are excluded from values returned by `Get`.
+// See `Ini.SetWantStripInlineComments`. Defaults to false, and applies to
+// subsequent loads. Comments are retained by `Set` regardless.
+func (doc *Document) SetWantStripInlineComments(b bool) {
+	doc.mutex.Lock()
+	doc.opts.stripInlineComments = b
+	doc.mutex.Unlock()
+}
+
+// ShouldStripInlineComments gets the flag determining if comments following
+// a value on the same line are excluded from values returned by `Get`.
+func (doc *Document) ShouldStripInlineComments() (b bool) {
+	doc.mutex.RLock()
+	b = doc.opts.stripInlineComments
+	doc.mutex.RUnlock()
+	return
+}
+
+// LoadFromFilespec loads a `Document` from string containing path and filename.
+func (doc *Document) LoadFromFilespec(filespec string) error {
+	data, err := ioutil.ReadFile(filespec)
+	if err != nil {
+		return err
+	}
+	return doc.LoadFromNamedString(string(data), filespec)
+}
+
+// LoadFromReader loads a `Document` from an io.Reader.
+func (doc *Document) LoadFromReader(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return doc.LoadFromString(string(data))
+}
+
+// LoadFromString parses a `Document` from a string.
+func (doc *Document) LoadFromString(s string) error {
+	return doc.LoadFromNamedString(s, "")
+}
+
+// LoadFromNamedString parses a `Document` from a string read from `filespec`,
+// which is used when reporting errors.
+//
+// Lines that cannot be parsed are retained as is, and reported as `ParseError`s
+// aggregated in a `merror.MError`, meaning the `Document` is usable even when
+// an error is returned.
+func (doc *Document) LoadFromNamedString(s string, filespec string) error {
+	doc.mutex.RLock()
+	opts := doc.opts
+	doc.mutex.RUnlock()
+
+	merr := merror.New()
+	texts, ends := splitRawLines(s)
+	entries := make([]*docEntry, 0, len(texts))
+	section := ""
+
+	rawText := func(first, last int) string {
+		sb := &strings.Builder{}
+		for i := first; i <= last; i++ {
+			sb.WriteString(texts[i])
+			sb.WriteString(ends[i])
+		}
+		return sb.String()
+	}
+
+	for i := 0; i < len(texts); i++ {
+		raw := texts[i]
+		sub := strings.TrimSpace(raw)
+		entry := &docEntry{kind: entryOther, section: section, raw: rawText(i, i)}
+
+		if name, ok := parseSection(sub); ok {
+			section = name
+			entry.kind = entrySection
+			entry.section = name
+		} else if _, ok := parseInclude(sub); !ok && sub != "" && !isComment(sub) {
+			ln := line{text: sub, file: filespec, num: i + 1, col: strings.Index(raw, sub) + 1}
+			last := i
+			if strings.Contains(sub, "=") {
+				last = joinContinuations(&ln, texts, i, opts)
+			}
+			if k, v, comment, err := parseProp(ln.text, opts); err != nil {
+				merr.Append(ln.errorf(err.column, "%s", err.msg))
+			} else if !comment {
+				entry.kind = entryProp
+				entry.key = k
+				entry.val = v
+			}
+			entry.raw = rawText(i, last)
+			i = last
+		}
+		entries = append(entries, entry)
+	}
+
+	doc.mutex.Lock()
+	doc.entries = entries
+	doc.mutex.Unlock()
+	return merr.ErrorOrNil()
+}
+
+// splitRawLines splits `str` into lines, returning the text of each line and
+// its terminator separately. See `buildLines` for the terminators supported.
+// The terminator of the last line is empty if `str` does not end with one.
+func splitRawLines(str string) (texts []string, ends []string) {
+	iBegin := 0
+	for iPos := 0; iPos < len(str); iPos++ {
+		ch := str[iPos]
+		if ch != LF && ch != CR {
+			continue
+		}
+		end := iPos + 1
+		if ch == CR && end < len(str) && str[end] == LF {
+			end++
+		}
+		texts = append(texts, str[iBegin:iPos])
+		ends = append(ends, str[iPos:end])
+		iPos = end - 1
+		iBegin = end
+	}
+	if iBegin < len(str) {
+		texts = append(texts, str[iBegin:])
+		ends = append(ends, "")
+	}
+	return texts, ends
+}
+
+// WriteTo writes the `Document` to `w`.
+func (doc *Document) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, doc.String())
+	return int64(n), err
+}
+
+// WriteToFilespec writes the `Document` to the named file, creating it if
+// needed.
+func (doc *Document) WriteToFilespec(filespec string) error {
+	return ioutil.WriteFile(filespec, []byte(doc.String()), os.FileMode(0644))
+}
+
+// String returns the contents of the `Document`.
+func (doc *Document) String() string {
+	doc.mutex.RLock()
+	defer doc.mutex.RUnlock()
+
+	sb := &strings.Builder{}
+	for _, entry := range doc.entries {
+		sb.WriteString(entry.raw)
+	}
+	return sb.String()
+}
+
+// Get returns the value of the specified key in the named section, or
+// `ok=false` if the key does not exist. Properties not in a named section
+// are in the section named with an empty string (""). If a key appears
+// more than once then the last value is returned.
+func (doc *Document) Get(section string, key string) (val string, ok bool) {
+	doc.mutex.RLock()
+	defer doc.mutex.RUnlock()
+
+	if idx := doc.findProp(section, key); idx != -1 {
+		return doc.entries[idx].val, true
+	}
+	return "", false
+}
+
+// Set sets the value of the specified key in the named section, replacing
+// the existing line(s) if the key exists. Otherwise the key is added after
+// the last key in the section, and the section is added if needed.
+// Values are quoted where needed (see `QuoteValue`). An error is returned if
+// the key or section name cannot be written (see `ValidKey` and
+// `ValidSectionName`).
+//
+// When inline comments are stripped (see `SetWantStripInlineComments`) and a
+// value on a single line is replaced, any comment following the value,
+// beginning with ';' or '#' preceded by whitespace, is retained. Otherwise
+// such comments are part of the value and are replaced along with it.
+func (doc *Document) Set(section string, key string, val string) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("key '%s' cannot be written in INI format", key)
+	}
+	if section != "" && !ValidSectionName(section) {
+		return fmt.Errorf("section name '%s' cannot be written in INI format", section)
+	}
+
+	doc.mutex.Lock()
+	defer doc.mutex.Unlock()
+
+	if idx := doc.findProp(section, key); idx != -1 {
+		entry := doc.entries[idx]
+		entry.val = val
+		entry.raw = replaceValue(entry.raw, val, doc.opts.stripInlineComments)
+		return nil
+	}
+
+	idx, indent := doc.findInsertion(section)
+	if idx == -1 {
+		doc.addSection(section)
+		idx = len(doc.entries)
+	}
+	if idx == len(doc.entries) {
+		doc.terminateLast()
+	}
+
+	entry := &docEntry{
+		kind:    entryProp,
+		section: section,
+		key:     key,
+		val:     val,
+		raw:     indent + key + " = " + QuoteValue(val) + doc.newline(),
+	}
+	doc.entries = append(doc.entries, nil)
+	copy(doc.entries[idx+1:], doc.entries[idx:])
+	doc.entries[idx] = entry
+	return nil
+}
+
+// Delete removes all occurrences of the specified key in the named section.
+// Returns false if the key does not exist.
+func (doc *Document) Delete(section string, key string) bool {
+	doc.mutex.Lock()
+	defer doc.mutex.Unlock()
+
+	entries := doc.entries[:0]
+	deleted := false
+	for _, entry := range doc.entries {
+		if entry.kind == entryProp && entry.section == section && entry.key == key {
+			deleted = true
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	doc.entries = entries
+	return deleted
+}
+
+// AddSection adds an empty section with the specified name to the end of the
+// `Document`. Returns false if the section already exists, or an error if the
+// name cannot be written (see `ValidSectionName`).
+func (doc *Document) AddSection(name string) (bool, error) {
+	if !ValidSectionName(name) {
+		return false, fmt.Errorf("section name '%s' cannot be written in INI format", name)
+	}
+
+	doc.mutex.Lock()
+	defer doc.mutex.Unlock()
+
+	if idx, _ := doc.findInsertion(name); idx != -1 {
+		return false, nil
+	}
+	doc.addSection(name)
+	return true, nil
+}
+
+// GetSectionNames returns the names of all sections, in the order they appear.
+// The section named with an empty string ("") is first if any properties
+// appear before the first section header.
+func (doc *Document) GetSectionNames() []string {
+	doc.mutex.RLock()
+	defer doc.mutex.RUnlock()
+
+	arr := make([]string, 0, 10)
+	seen := make(map[string]bool)
+	for _, entry := range doc.entries {
+		if (entry.kind == entrySection || entry.kind == entryProp) && !seen[entry.section] {
+			seen[entry.section] = true
+			arr = append(arr, entry.section)
+		}
+	}
+	return arr
+}
+
+// GetKeys returns the names of all keys in the specified section, in the
+// order they appear.
+func (doc *Document) GetKeys(section string) []string {
+	doc.mutex.RLock()
+	defer doc.mutex.RUnlock()
+
+	arr := make([]string, 0, 10)
+	seen := make(map[string]bool)
+	for _, entry := range doc.entries {
+		if entry.kind == entryProp && entry.section == section && !seen[entry.key] {
+			seen[entry.key] = true
+			arr = append(arr, entry.key)
+		}
+	}
+	return arr
+}
+
+// findProp returns the index of the last entry for the key, or -1 if not found.
+//
+// Must be called with `mutex` locked.
+func (doc *Document) findProp(section string, key string) int {
+	for i := len(doc.entries) - 1; i >= 0; i-- {
+		entry := doc.entries[i]
+		if entry.kind == entryProp && entry.section == section && entry.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// findInsertion returns the index at which a new key should be inserted into
+// the section, being after the last key of the last occurrence of the section,
+// or -1 if the section does not exist. The indentation of the preceding key is
+// also returned.
+//
+// Must be called with `mutex` locked.
+func (doc *Document) findInsertion(section string) (idx int, indent string) {
+	idx = -1
+	if section == "" {
+		idx = 0 // the unnamed section always exists
+	}
+	for i, entry := range doc.entries {
+		if entry.kind == entrySection && entry.section == section {
+			idx = i + 1
+			indent = ""
+		} else if entry.kind == entryProp && entry.section == section {
+			idx = i + 1
+			indent = entry.raw[:len(entry.raw)-len(strings.TrimLeft(entry.raw, " \t"))]
+		}
+	}
+	return idx, indent
+}
+
+// addSection appends a section header, preceded by a blank line.
+//
+// Must be called with `mutex` locked.
+func (doc *Document) addSection(name string) {
+	doc.terminateLast()
+	nl := doc.newline()
+	if n := len(doc.entries); n > 0 && strings.TrimSpace(doc.entries[n-1].raw) != "" {
+		doc.entries = append(doc.entries, &docEntry{kind: entryOther, section: doc.entries[n-1].section, raw: nl})
+	}
+	doc.entries = append(doc.entries, &docEntry{kind: entrySection, section: name, raw: "[" + name + "]" + nl})
+}
+
+// terminateLast adds a line terminator to the last line if missing, so that
+// lines can be appended.
+//
+// Must be called with `mutex` locked.
+func (doc *Document) terminateLast() {
+	if n := len(doc.entries); n > 0 {
+		last := doc.entries[n-1]
+		if !strings.HasSuffix(last.raw, "\n") && !strings.HasSuffix(last.raw, "\r") {
+			last.raw += doc.newline()
+		}
+	}
+}
+
+// newline returns the line terminator used by the first line of the
+// `Document`, or "\n" if none.
+//
+// Must be called with `mutex` locked.
+func (doc *Document) newline() string {
+	for _, entry := range doc.entries {
+		if i := strings.IndexAny(entry.raw, "\r\n"); i != -1 {
+			if strings.HasPrefix(entry.raw[i:], "\r\n") {
+				return "\r\n"
+			}
+			return entry.raw[i : i+1]
+		}
+	}
+	return "\n"
+}
+
+// replaceValue returns the raw text of a property with the value replaced,
+// retaining the text up to and including the '=' and any whitespace following,
+// any trailing comment if `keepComment` is set and the property is on a single
+// line, and the line terminator.
+func replaceValue(raw string, val string, keepComment bool) string {
+	iEqPos := strings.Index(raw, "=")
+	prefix := raw[:iEqPos+1]
+	rest := raw[iEqPos+1:]
+	space := rest[:len(rest)-len(strings.TrimLeft(rest, " \t"))]
+	if space == "" && strings.TrimSpace(strings.SplitN(rest, "\n", 2)[0]) == "" {
+		space = " " // value was on the following lines
+	}
+
+	end := ""
+	if strings.HasSuffix(raw, "\r\n") {
+		end = "\r\n"
+	} else if strings.HasSuffix(raw, "\n") || strings.HasSuffix(raw, "\r") {
+		end = raw[len(raw)-1:]
+	}
+
+	comment := ""
+	if body := rest[:len(rest)-len(end)]; keepComment && !strings.ContainsAny(body, "\r\n") {
+		comment = trailingComment(strings.TrimSpace(body))
+	}
+	return prefix + space + QuoteValue(val) + comment + end
+}
+
+// trailingComment returns the comment following a value, along with the
+// whitespace preceding it, or an empty string if none. Comments begin with
+// ';' or '#' at the start of the value, following a quoted value, or preceded
+// by whitespace.
+func trailingComment(val string) string {
+	start := 0
+	if strings.HasPrefix(val, tripleQuote) {
+		end := strings.Index(val[len(tripleQuote):], tripleQuote)
+		if end == -1 {
+			return ""
+		}
+		start = end + 2*len(tripleQuote)
+	} else if strings.HasPrefix(val, `"`) {
+		if _, rest, ok := unquote(val); ok {
+			start = len(val) - len(rest)
+		}
+	}
+
+	for i := start; i < len(val); i++ {
+		if (val[i] == ';' || val[i] == '#') && (i == start || val[i-1] == ' ' || val[i-1] == '\t') {
+			j := i
+			for j > start && (val[j-1] == ' ' || val[j-1] == '\t') {
+				j--
+			}
+			comment := val[j:]
+			if j == i {
+				comment = " " + comment
+			}
+			return comment
+		}
+	}
+	return ""
+}
@@ -0,0 +1,272 @@
+package ini_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wiggin77/cfg/ini"
+)
+
+const docSample = "# global settings\r\n" +
+	"name = app  ; inline\r\n" +
+	"\r\n" +
+	"[db]\r\n" +
+	"  ; connection\r\n" +
+	"  host=localhost\r\n" +
+	"  cert =\r\n" +
+	"      line1\r\n" +
+	"      line2\r\n" +
+	"\r\n" +
+	"# trailing comment\r\n" +
+	"[log]\r\n" +
+	"level = info"
+
+func loadDoc(t *testing.T, s string) *ini.Document {
+	t.Helper()
+	doc := &ini.Document{}
+	doc.SetWantJoinContinuations(true)
+	if err := doc.LoadFromString(s); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	samples := []string{docSample, "", "\n\n", "a=1", sample1, "bad line\n[sec]\nkey=\"\"\"\nx\n\"\"\"\n"}
+	for _, s := range samples {
+		doc := &ini.Document{}
+		_ = doc.LoadFromString(s)
+		buf := &bytes.Buffer{}
+		if _, err := doc.WriteTo(buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != s {
+			t.Errorf("WriteTo() = %q, want %q", buf.String(), s)
+		}
+	}
+}
+
+func TestDocumentGet(t *testing.T) {
+	doc := loadDoc(t, docSample)
+
+	if val, ok := doc.Get("db", "cert"); !ok || val != "line1\nline2" {
+		t.Errorf("Get(db, cert) = %q, %v", val, ok)
+	}
+	if val, ok := doc.Get("", "name"); !ok || val != "app  ; inline" {
+		t.Errorf("Get(, name) = %q, %v", val, ok)
+	}
+	if _, ok := doc.Get("db", "blap"); ok {
+		t.Error("Get(db, blap) expected ok=false")
+	}
+	if got, want := doc.GetSectionNames(), []string{"", "db", "log"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSectionNames() = %v, want %v", got, want)
+	}
+	if got, want := doc.GetKeys("db"), []string{"host", "cert"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetKeys(db) = %v, want %v", got, want)
+	}
+}
+
+func TestDocumentEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(doc *ini.Document)
+		want string
+	}{
+		{
+			name: "modify",
+			edit: func(doc *ini.Document) { doc.Set("db", "host", "db.example.com") },
+			want: strings.Replace(docSample, "host=localhost", "host=db.example.com", 1),
+		},
+		{
+			name: "modify with comment",
+			edit: func(doc *ini.Document) { doc.Set("", "name", "web") },
+			want: strings.Replace(docSample, "name = app  ; inline", "name = web", 1),
+		},
+		{
+			name: "modify multi-line",
+			edit: func(doc *ini.Document) { doc.Set("db", "cert", "x\ny") },
+			want: strings.Replace(docSample, "cert =\r\n      line1\r\n      line2\r\n", "cert = \"x\\ny\"\r\n", 1),
+		},
+		{
+			name: "modify last line",
+			edit: func(doc *ini.Document) { doc.Set("log", "level", " debug") },
+			want: strings.Replace(docSample, "level = info", "level = \" debug\"", 1),
+		},
+		{
+			name: "add key",
+			edit: func(doc *ini.Document) { doc.Set("db", "port", "5432") },
+			want: strings.Replace(docSample, "      line2\r\n", "      line2\r\n  port = 5432\r\n", 1),
+		},
+		{
+			name: "add key to last section",
+			edit: func(doc *ini.Document) { doc.Set("log", "file", "app.log") },
+			want: docSample + "\r\nfile = app.log\r\n",
+		},
+		{
+			name: "add section",
+			edit: func(doc *ini.Document) { doc.Set("cache", "size", "10") },
+			want: docSample + "\r\n\r\n[cache]\r\nsize = 10\r\n",
+		},
+		{
+			name: "delete",
+			edit: func(doc *ini.Document) { doc.Delete("db", "host") },
+			want: strings.Replace(docSample, "  host=localhost\r\n", "", 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := loadDoc(t, docSample)
+			tt.edit(doc)
+			if got := doc.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			// edited documents must still parse
+			loadDoc(t, doc.String())
+		})
+	}
+}
+
+func TestDocumentSetKeepsComment(t *testing.T) {
+	tests := []struct {
+		raw   string
+		val   string
+		strip bool
+		want  string
+	}{
+		{"a = 1 ; keep me\n", "3", true, "a = 3 ; keep me\n"},
+		{"a=1\t# keep me", "3", true, "a=3\t# keep me"},
+		{"a = \"x ; y\" # keep me\n", "3", true, "a = 3 # keep me\n"},
+		{"a = \"x\";keep me\n", "3", true, "a = 3 ;keep me\n"},
+		{"a = ; keep me\n", "3", true, "a = 3 ; keep me\n"},
+		{"a = \"\"\"x ; y\"\"\" ; keep me\n", "3", true, "a = 3 ; keep me\n"},
+		{"a = x;y\n", "3", true, "a = 3\n"},
+		{"a = 1 ; keep me\n", "2 ; not a comment", true, "a = \"2 ; not a comment\" ; keep me\n"},
+		{"a = 1 ; part of value\n", "3", false, "a = 3\n"},
+		{"a=1\t# part of value", "3", false, "a=3"},
+	}
+	for _, tt := range tests {
+		doc := &ini.Document{}
+		doc.SetWantStripInlineComments(tt.strip)
+		if err := doc.LoadFromString(tt.raw); err != nil {
+			t.Fatal(err)
+		}
+		if err := doc.Set("", "a", tt.val); err != nil {
+			t.Fatal(err)
+		}
+		if got := doc.String(); got != tt.want {
+			t.Errorf("Set(%q) on %q = %q, want %q", tt.val, tt.raw, got, tt.want)
+		}
+
+		// value reads back with the same options
+		doc2 := &ini.Document{}
+		doc2.SetWantStripInlineComments(tt.strip)
+		if err := doc2.LoadFromString(doc.String()); err != nil {
+			t.Fatal(err)
+		}
+		if val, _ := doc2.Get("", "a"); val != tt.val {
+			t.Errorf("Get() after Set(%q) on %q = %q", tt.val, tt.raw, val)
+		}
+	}
+}
+
+func TestDocumentAddSection(t *testing.T) {
+	doc := &ini.Document{}
+	if ok, err := doc.AddSection("sec1"); !ok || err != nil {
+		t.Errorf("AddSection(sec1) expected true, got %v, err=%v", ok, err)
+	}
+	if ok, err := doc.AddSection("sec1"); ok || err != nil {
+		t.Errorf("AddSection(sec1) expected false for existing section, got %v, err=%v", ok, err)
+	}
+	if _, err := doc.AddSection("bad]"); err == nil {
+		t.Error("AddSection(bad]) expected error")
+	}
+	doc.Set("sec1", "key", "val")
+	doc.Set("", "global", "1")
+	if doc.Delete("sec1", "blap") {
+		t.Error("Delete(sec1, blap) expected false")
+	}
+
+	want := "global = 1\n[sec1]\nkey = val\n"
+	if got := doc.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestDocumentSetInvalid(t *testing.T) {
+	tests := []struct {
+		section string
+		key     string
+	}{
+		{"", "include"},
+		{"", "!include x.ini"},
+		{"", "[sec]"},
+		{"", "; comment"},
+		{"", "a=b"},
+		{"", ""},
+		{"sec]", "key"},
+		{"sec\nx", "key"},
+		{" sec", "key"},
+	}
+	for _, tt := range tests {
+		doc := loadDoc(t, docSample)
+		if err := doc.Set(tt.section, tt.key, "x.ini"); err == nil {
+			t.Errorf("Set(%q, %q) expected error", tt.section, tt.key)
+		}
+		if got := doc.String(); got != docSample {
+			t.Errorf("Set(%q, %q) modified document: %q", tt.section, tt.key, got)
+		}
+	}
+}
+
+func TestIniWriteTo(t *testing.T) {
+	const s = "b=2\na=1\n[sec2]\nz = \"  padded\"\n[sec1]\ny=multi\n  line\nx = val ; not a comment\n"
+	i := ini.Ini{}
+	i.SetWantJoinContinuations(true)
+	if err := i.LoadFromString(s); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := i.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "a = 1\nb = 2\n\n[sec1]\nx = \"val ; not a comment\"\ny = \"multi\\nline\"\n\n[sec2]\nz = \"  padded\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteTo() = %q, want %q", got, want)
+	}
+
+	// written output parses to the same values, even when stripping comments
+	i2 := ini.Ini{}
+	i2.SetWantStripInlineComments(true)
+	if err := i2.LoadFromString(buf.String()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(i.ToMap(), i2.ToMap()) {
+		t.Errorf("ToMap() = %v, want %v", i2.ToMap(), i.ToMap())
+	}
+}
+
+func TestQuoteValue(t *testing.T) {
+	tests := []struct {
+		val  string
+		want string
+	}{
+		{"", ""},
+		{"plain value", "plain value"},
+		{" padded", `" padded"`},
+		{"a\nb", `"a\nb"`},
+		{`"quoted"`, `"\"quoted\""`},
+		{"#hash", `"#hash"`},
+		{"a ; b", `"a ; b"`},
+		{"a;b", "a;b"},
+		{`c:\dir\`, `"c:\\dir\\"`},
+		{"héllo", "héllo"},
+	}
+	for _, tt := range tests {
+		if got := ini.QuoteValue(tt.val); got != tt.want {
+			t.Errorf("QuoteValue(%q) = %s, want %s", tt.val, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package ini
+
+import (
+	"fmt"
+)
+
+// ParseError describes a problem parsing a single line of an INI file.
+// Multiple errors are aggregated and returned as a `merror.MError`.
+type ParseError struct {
+	// File is the path of the file containing the error, or empty if
+	// the INI was not loaded from a file.
+	File string
+	// Line is the 1-based line number.
+	Line int
+	// Column is the 1-based column where the problem was found.
+	Column int
+	// Text is the offending line, with surrounding whitespace removed.
+	Text string
+	// Msg describes the problem.
+	Msg string
+}
+
+// Error returns a string representation of the `ParseError` in the
+// form `file:line:column: msg: 'text'`.
+func (pe *ParseError) Error() string {
+	file := pe.File
+	if file == "" {
+		file = "<string>"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: '%s'", file, pe.Line, pe.Column, pe.Msg, pe.Text)
+}
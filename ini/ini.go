@@ -17,6 +17,93 @@ type Ini struct {
 	mutex sync.RWMutex
 	m     map[string]*Section
 	lm    time.Time
+	mode  ParseMode
+	opts  parseOptions
+	incs  []string
+}
+
+// ParseMode determines how an `Ini` handles errors encountered while loading.
+// In all modes the errors are returned by the `Load...` method.
+type ParseMode int
+
+const (
+	// ParseKeepLastGood rejects the whole INI if any errors are encountered,
+	// retaining the previously loaded contents. This is the default.
+	ParseKeepLastGood ParseMode = iota
+
+	// ParseStrict rejects the whole INI if any errors are encountered,
+	// leaving the `Ini` empty.
+	ParseStrict
+
+	// ParseLenient loads everything that parses successfully, meaning any
+	// errors returned should be treated as warnings.
+	ParseLenient
+)
+
+// String returns a string representation of the `ParseMode`.
+func (mode ParseMode) String() string {
+	switch mode {
+	case ParseKeepLastGood:
+		return "keep-last-good"
+	case ParseStrict:
+		return "strict"
+	case ParseLenient:
+		return "lenient"
+	}
+	return "unknown"
+}
+
+// SetParseMode sets how errors encountered while loading are handled.
+// Defaults to `ParseKeepLastGood`.
+func (ini *Ini) SetParseMode(mode ParseMode) {
+	ini.mutex.Lock()
+	ini.mode = mode
+	ini.mutex.Unlock()
+}
+
+// GetParseMode returns how errors encountered while loading are handled.
+func (ini *Ini) GetParseMode() (mode ParseMode) {
+	ini.mutex.RLock()
+	mode = ini.mode
+	ini.mutex.RUnlock()
+	return
+}
+
+// SetWantStripInlineComments sets the flag determining if comments following
+// a value on the same line are removed. Comments begin with ';' or '#' preceded
+// by whitespace, e.g. `key = value ; comment`. Use a quoted value to include
+// these characters. Defaults to false.
+func (ini *Ini) SetWantStripInlineComments(b bool) {
+	ini.mutex.Lock()
+	ini.opts.stripInlineComments = b
+	ini.mutex.Unlock()
+}
+
+// ShouldStripInlineComments gets the flag determining if comments following
+// a value on the same line are removed.
+func (ini *Ini) ShouldStripInlineComments() (b bool) {
+	ini.mutex.RLock()
+	b = ini.opts.stripInlineComments
+	ini.mutex.RUnlock()
+	return
+}
+
+// SetWantJoinContinuations sets the flag determining if values may span
+// multiple lines using a trailing backslash or indented continuation lines.
+// Values enclosed in `"""` may always span multiple lines. Defaults to false.
+func (ini *Ini) SetWantJoinContinuations(b bool) {
+	ini.mutex.Lock()
+	ini.opts.joinContinuations = b
+	ini.mutex.Unlock()
+}
+
+// ShouldJoinContinuations gets the flag determining if values may span
+// multiple lines using a trailing backslash or indented continuation lines.
+func (ini *Ini) ShouldJoinContinuations() (b bool) {
+	ini.mutex.RLock()
+	b = ini.opts.joinContinuations
+	ini.mutex.RUnlock()
+	return
 }
 
 // LoadFromFilespec loads an INI file from string containing path and filename.
@@ -25,6 +112,7 @@ func (ini *Ini) LoadFromFilespec(filespec string) error {
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 	return ini.LoadFromFile(f)
 }
 
@@ -37,7 +125,11 @@ func (ini *Ini) LoadFromFile(file *os.File) error {
 	}
 	lm := fi.ModTime()
 
-	if err := ini.LoadFromReader(file); err != nil {
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	if err := ini.LoadFromNamedString(string(data), file.Name()); err != nil {
 		return err
 	}
 	ini.lm = lm
@@ -55,15 +147,60 @@ func (ini *Ini) LoadFromReader(reader io.Reader) error {
 
 // LoadFromString parses an INI from a string .
 func (ini *Ini) LoadFromString(s string) error {
-	m, err := getSections(s)
+	return ini.LoadFromNamedString(s, "")
+}
+
+// LoadFromNamedString parses an INI from a string containing the contents
+// of the file `filespec`, which is used to resolve relative include paths.
+//
+// Include directives, either `!include path` or `include = path`, are replaced
+// by the contents of the included file. Relative paths are resolved relative
+// to the directory containing the including file, or the current directory if
+// `filespec` is empty. Paths may contain glob patterns (see `filepath.Match`)
+// to include multiple files in lexical order.
+//
+// Any errors are handled according to the `ParseMode`.
+func (ini *Ini) LoadFromNamedString(s string, filespec string) error {
+	ini.mutex.RLock()
+	opts := ini.opts
+	ini.mutex.RUnlock()
+
+	m, incs, err := getSections(s, filespec, opts)
+
+	ini.mutex.Lock()
+	defer ini.mutex.Unlock()
+
+	ini.incs = incs
+
 	if err != nil {
-		return err
+		switch ini.mode {
+		case ParseStrict:
+			m = make(map[string]*Section)
+		case ParseLenient:
+			// keep partial results
+			if m == nil {
+				m = make(map[string]*Section)
+			}
+		default:
+			return err
+		}
 	}
-	ini.mutex.Lock()
 	ini.m = m
 	ini.lm = time.Now()
-	ini.mutex.Unlock()
-	return nil
+	return err
+}
+
+// GetIncludes returns the absolute paths of the files included by the most
+// recent load, in the order first included, regardless of any errors. Paths of
+// included files that could not be read are also returned. Files matching a
+// glob pattern are only returned if they existed at the time of the load.
+func (ini *Ini) GetIncludes() []string {
+	ini.mutex.RLock()
+	defer ini.mutex.RUnlock()
+
+	arr := make([]string, len(ini.incs))
+	copy(arr, ini.incs)
+	return arr
 }
 
 // GetLastModified returns the last modified timestamp of the
@@ -1,6 +1,9 @@
 package ini_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -170,3 +173,185 @@ func TestGetKeys(t *testing.T) {
 		t.Errorf("expected error")
 	}
 }
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		filespec := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filespec), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filespec, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "initest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, dir, map[string]string{
+		"app.ini":              "name=app\n!include shared/base.ini\n[db]\nhost=override\ninclude = conf.d/*.ini\n",
+		"shared/base.ini":      "[db]\nhost=localhost\nport=5432\n",
+		"conf.d/10-first.ini":  "[cache]\nsize=10\n",
+		"conf.d/20-second.ini": "[cache]\nsize=20\nttl=60\n",
+	})
+
+	i := ini.Ini{}
+	if err := i.LoadFromFilespec(filepath.Join(dir, "app.ini")); err != nil {
+		t.Fatalf("LoadFromFilespec; expected err=nil, got err=%v", err)
+	}
+	want := map[string]string{
+		"name":       "app",
+		"db.host":    "override",
+		"db.port":    "5432",
+		"cache.size": "20",
+		"cache.ttl":  "60",
+	}
+	if got := i.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToMap() = %v, want %v", got, want)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantIncs := []string{
+		filepath.Join(abs, "shared", "base.ini"),
+		filepath.Join(abs, "conf.d", "10-first.ini"),
+		filepath.Join(abs, "conf.d", "20-second.ini"),
+	}
+	if got := i.GetIncludes(); !reflect.DeepEqual(got, wantIncs) {
+		t.Errorf("GetIncludes() = %v, want %v", got, wantIncs)
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "initest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, dir, map[string]string{
+		"cycle1.ini":     "a=1\n!include cycle2.ini\n",
+		"cycle2.ini":     "b=2\n!include sub/cycle3.ini\n",
+		"sub/cycle3.ini": "c=3\n!include ../cycle1.ini\n",
+		"missing.ini":    "a=1\n!include nested.ini\n",
+		"nested.ini":     "b=2\n!include nope.ini\n",
+		"noglob.ini":     "a=1\n!include nope/*.ini\n",
+	})
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr []string
+	}{
+		{"cycle", "cycle1.ini", []string{"include cycle:", "cycle1.ini -> ", "cycle2.ini -> ", "cycle3.ini -> "}},
+		{"missing", "missing.ini", []string{"error including", "nope.ini", "missing.ini -> ", "nested.ini"}},
+		{"noglob", "noglob.ini", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := ini.Ini{}
+			err := i.LoadFromFilespec(filepath.Join(dir, tt.file))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("LoadFromFilespec; expected err=nil, got err=%v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("LoadFromFilespec; expected error")
+			}
+			for _, s := range tt.wantErr {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("LoadFromFilespec; expected error containing %q, got %v", s, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseModes(t *testing.T) {
+	const good = "[sec]\nkey1=one\nkey2=two\n"
+	const bad = "[sec]\nkey1=uno\nbroken\n"
+
+	tests := []struct {
+		mode  ini.ParseMode
+		want  map[string]string
+		wantS string
+	}{
+		{mode: ini.ParseKeepLastGood, want: map[string]string{"sec.key1": "one", "sec.key2": "two"}, wantS: "keep-last-good"},
+		{mode: ini.ParseStrict, want: map[string]string{}, wantS: "strict"},
+		{mode: ini.ParseLenient, want: map[string]string{"sec.key1": "uno"}, wantS: "lenient"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantS, func(t *testing.T) {
+			if got := tt.mode.String(); got != tt.wantS {
+				t.Errorf("String() = %s, want %s", got, tt.wantS)
+			}
+			ini := ini.Ini{}
+			ini.SetParseMode(tt.mode)
+			if got := ini.GetParseMode(); got != tt.mode {
+				t.Errorf("GetParseMode() = %v, want %v", got, tt.mode)
+			}
+			if err := ini.LoadFromString(good); err != nil {
+				t.Fatal(err)
+			}
+			if err := ini.LoadFromString(bad); err == nil {
+				t.Error("expected error")
+			}
+			if got := ini.ToMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInlineComments(t *testing.T) {
+	const s = "[sec]\nkey1 = val1 ; comment\nkey2 = \"val2 ; not a comment\"  # comment\n"
+
+	ini := ini.Ini{}
+	if ini.ShouldStripInlineComments() {
+		t.Error("ShouldStripInlineComments() should default to false")
+	}
+	ini.SetWantStripInlineComments(true)
+	if err := ini.LoadFromString(s); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"sec.key1": "val1", "sec.key2": "val2 ; not a comment"}
+	if got := ini.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToMap() = %v, want %v", got, want)
+	}
+}
+
+func TestJoinContinuations(t *testing.T) {
+	const s = "key1 =\n  key2 = b\ndir = C:\\temp\\\nname = x\n"
+
+	ini := ini.Ini{}
+	if ini.ShouldJoinContinuations() {
+		t.Error("ShouldJoinContinuations() should default to false")
+	}
+	if err := ini.LoadFromString(s); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"key1": "", "key2": "b", "dir": "C:\\temp\\", "name": "x"}
+	if got := ini.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToMap() = %v, want %v", got, want)
+	}
+
+	ini.SetWantJoinContinuations(true)
+	if err := ini.LoadFromString(s); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{"key1": "key2 = b", "dir": "C:\\tempname = x"}
+	if got := ini.ToMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ToMap() = %v, want %v", got, want)
+	}
+}
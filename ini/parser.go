@@ -2,7 +2,12 @@ package ini
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/wiggin77/merror"
 )
@@ -20,52 +25,138 @@ const CR byte = 0x0D
 // with an empty string ("").  Also true for Linux-style config files where all props
 // are outside a named section.
 //
+// Include directives are replaced with the contents of the included files, with
+// relative paths resolved relative to the directory containing `filespec`, or the
+// current directory if `filespec` is empty. The absolute paths of the included files
+// are returned, including any that could not be read. See `includer.expand`.
+//
+// Sections appearing more than once are combined, with later properties overriding
+// earlier ones.
+//
 // Any errors encountered are aggregated and returned, along with the partially parsed
 // sections.
-func getSections(str string) (map[string]*Section, error) {
+//
+// Parsing errors are returned as `ParseError`s containing the file name and line number.
+func getSections(str string, filespec string, opts parseOptions) (map[string]*Section, []string, error) {
 	merr := merror.New()
 	mapSections := make(map[string]*Section)
-	lines := buildLineArray(str)
+	inc := &includer{opts: opts, merr: merr}
+	lines := inc.expand(buildLines(str, filespec, opts), filespec, nil)
 	section := newSection("")
 
-	for _, line := range lines {
-		name, ok := parseSection(line)
+	addSection := func(sec *Section) {
+		if existing, ok := mapSections[sec.GetName()]; ok {
+			existing.combine(sec)
+		} else {
+			mapSections[sec.GetName()] = sec
+		}
+	}
+
+	for _, ln := range lines {
+		name, ok := parseSection(ln.text)
 		if ok {
 			// A section name encountered. Stop processing the current one.
 			// Don't add the current section to the map if the section name is blank
 			// and the prop map is empty.
 			nameCurr := section.GetName()
 			if nameCurr != "" || section.hasKeys() {
-				mapSections[nameCurr] = section
+				addSection(section)
 			}
 			// Start processing a new section.
 			section = newSection(name)
 		} else {
 			// Parse the property and add to the current section, or ignore if comment.
-			if k, v, comment, err := parseProp(line); !comment && err == nil {
+			if k, v, comment, err := parseProp(ln.text, opts); !comment && err == nil {
 				section.setProp(k, v)
 			} else if err != nil {
-				merr.Append(err) // aggregate errors
+				merr.Append(ln.errorf(err.column, "%s", err.msg)) // aggregate errors
 			}
 		}
 
 	}
 	// If the current section is not empty, add it.
 	if section.hasKeys() {
-		mapSections[section.GetName()] = section
+		addSection(section)
+	}
+	return mapSections, inc.files, merr.ErrorOrNil()
+}
+
+// parseOptions contains options affecting how property values are parsed.
+type parseOptions struct {
+	stripInlineComments bool
+	joinContinuations   bool // join backslash and indented continuation lines
+}
+
+// line is a single line of an INI file, along with its position.
+type line struct {
+	text string // surrounding whitespace removed
+	file string
+	num  int // 1-based line number
+	col  int // 1-based column where `text` begins
+}
+
+// errorf creates a `ParseError` for this line. `col` is the 1-based column
+// within the trimmed text.
+func (ln line) errorf(col int, format string, args ...interface{}) *ParseError {
+	if col < 1 {
+		col = 1
+	}
+	return &ParseError{
+		File:   ln.file,
+		Line:   ln.num,
+		Column: ln.col + col - 1,
+		Text:   ln.text,
+		Msg:    fmt.Sprintf(format, args...),
 	}
-	return mapSections, merr.ErrorOrNil()
 }
 
 // buildLineArray parses the given string buffer and creates a list of strings,
 // one for each line in the string buffer.
 //
+// See `buildLines`.
+func buildLineArray(str string) []string {
+	lines := buildLines(str, "", parseOptions{})
+	arr := make([]string, 0, len(lines))
+	for _, ln := range lines {
+		arr = append(arr, ln.text)
+	}
+	return arr
+}
+
+// buildLines parses the given string buffer, read from `filespec`, and creates
+// a list of lines, one for each line in the string buffer.
+//
 // A line is considered to be terminated by any one of a line feed ('\n'),
 // a carriage return ('\r'), or a carriage return followed immediately by a
 // linefeed.
 //
-// Lines prefixed with ';' or '#' are considered comments and skipped.
-func buildLineArray(str string) []string {
+// Lines prefixed with ';' or '#' are considered comments and skipped, as are
+// blank lines, however line numbers account for them.
+//
+// Properties spanning multiple lines are joined into a single line. See
+// `joinContinuations`.
+func buildLines(str string, filespec string, opts parseOptions) []line {
+	raws := splitLines(str)
+	arr := make([]line, 0, 10)
+
+	for i := 0; i < len(raws); i++ {
+		raw := raws[i]
+		sub := strings.TrimSpace(raw)
+		if sub == "" || isComment(sub) {
+			continue
+		}
+		ln := line{text: sub, file: filespec, num: i + 1, col: strings.Index(raw, sub) + 1}
+		if _, ok := parseSection(sub); !ok && strings.Contains(sub, "=") {
+			i = joinContinuations(&ln, raws, i, opts)
+		}
+		arr = append(arr, ln)
+	}
+	return arr
+}
+
+// splitLines splits the string buffer into lines, without removing whitespace.
+// See `buildLines` for the line terminators supported.
+func splitLines(str string) []string {
 	arr := make([]string, 0, 10)
 	str = str + "\n"
 
@@ -76,11 +167,7 @@ func buildLineArray(str string) []string {
 	for iPos < iLen {
 		ch = str[iPos]
 		if ch == LF || ch == CR {
-			sub := str[iBegin:iPos]
-			sub = strings.TrimSpace(sub)
-			if sub != "" && !strings.HasPrefix(sub, ";") && !strings.HasPrefix(sub, "#") {
-				arr = append(arr, sub)
-			}
+			arr = append(arr, str[iBegin:iPos])
 			iPos++
 			if ch == CR && iPos < iLen && str[iPos] == LF {
 				iPos++
@@ -93,6 +180,209 @@ func buildLineArray(str string) []string {
 	return arr
 }
 
+// isComment returns true if the trimmed line is a comment.
+func isComment(str string) bool {
+	return strings.HasPrefix(str, ";") || strings.HasPrefix(str, "#")
+}
+
+// tripleQuote delimits multi-line values.
+const tripleQuote = `"""`
+
+// joinContinuations appends to the property `ln`, found at `raws[i]`, any lines
+// that continue its value, and returns the index of the last line consumed.
+//
+// A value beginning with `"""` continues until the next `"""`, with the lines
+// in between included verbatim and separated by '\n'. Text immediately following
+// the opening `"""` and preceding the closing `"""` is included only if not blank.
+//
+// If `opts.joinContinuations` is true, a value can also span multiple lines in the
+// following ways:
+//
+// * A line ending with a single backslash is joined with the next line, with the
+// backslash and the next line's leading whitespace removed.
+// * Lines immediately following that are indented more than the key are appended,
+// separated by '\n', with surrounding whitespace removed. If the key has a value on
+// the same line then continuation lines must not contain '=', so that indented
+// properties are not mistaken for continuations. Indented comments are skipped, while
+// blank lines and section headers end the value.
+func joinContinuations(ln *line, raws []string, i int, opts parseOptions) int {
+	iEqPos := strings.Index(ln.text, "=")
+	val := strings.TrimSpace(ln.text[iEqPos+1:])
+
+	if strings.HasPrefix(val, tripleQuote) {
+		if len(val) >= 2*len(tripleQuote) && strings.Contains(val[len(tripleQuote):], tripleQuote) {
+			return i // entire value on one line
+		}
+		content := make([]string, 0, 10)
+		if first := val[len(tripleQuote):]; strings.TrimSpace(first) != "" {
+			content = append(content, first)
+		}
+		for j := i + 1; j < len(raws); j++ {
+			k := strings.Index(raws[j], tripleQuote)
+			if k == -1 {
+				content = append(content, raws[j])
+				continue
+			}
+			if last := raws[j][:k]; strings.TrimSpace(last) != "" {
+				content = append(content, last)
+			}
+			ln.text = ln.text[:iEqPos+1] + " " + tripleQuote + strings.Join(content, "\n") +
+				strings.TrimSpace(raws[j][k:])
+			return j
+		}
+		return i // unterminated; reported by `parseProp`
+	}
+
+	if !opts.joinContinuations {
+		return i
+	}
+
+	for strings.HasSuffix(ln.text, `\`) && !strings.HasSuffix(ln.text, `\\`) && i+1 < len(raws) {
+		i++
+		ln.text = strings.TrimSuffix(ln.text, `\`) + strings.TrimSpace(raws[i])
+	}
+
+	hasVal := strings.TrimSpace(ln.text[iEqPos+1:]) != ""
+	indent := ln.col - 1
+	for i+1 < len(raws) {
+		next := raws[i+1]
+		sub := strings.TrimSpace(next)
+		if sub == "" || strings.Index(next, sub) <= indent {
+			break
+		}
+		if isComment(sub) {
+			i++
+			continue
+		}
+		if _, ok := parseSection(sub); ok {
+			break
+		}
+		if hasVal && strings.Contains(sub, "=") {
+			break
+		}
+		ln.text += "\n" + sub
+		i++
+	}
+	return i
+}
+
+// includer expands include directives, aggregating errors and recording the
+// files included.
+type includer struct {
+	opts  parseOptions
+	merr  *merror.MError
+	files []string // absolute paths of files included, or that failed to be read
+}
+
+// expand returns a copy of the lines with each include directive replaced
+// by the lines of the included file(s), recursively. See `parseInclude`.
+//
+// Relative include paths are resolved relative to the directory containing `filespec`.
+// Paths containing glob patterns (see `filepath.Match`) include every matching file in
+// lexical order, and match nothing without error. `chain` contains the absolute paths
+// of the files currently being included and is used to detect cycles.
+//
+// Errors are aggregated into `merr` and report the chain of includes.
+func (inc *includer) expand(lines []line, filespec string, chain []string) []line {
+	dir := ""
+	if filespec != "" {
+		dir = filepath.Dir(filespec)
+		if abs, err := filepath.Abs(filespec); err == nil {
+			chain = append(chain[:len(chain):len(chain)], abs)
+		}
+	}
+
+	arr := make([]line, 0, len(lines))
+	for _, ln := range lines {
+		path, ok := parseInclude(ln.text)
+		if !ok {
+			arr = append(arr, ln)
+			continue
+		}
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		var files []string
+		if strings.ContainsAny(path, "*?[") {
+			matches, err := filepath.Glob(path)
+			if err != nil {
+				inc.merr.Append(includeError(ln, path, chain, err))
+				continue
+			}
+			sort.Strings(matches)
+			files = matches
+		} else {
+			files = []string{path}
+		}
+
+		for _, file := range files {
+			arr = append(arr, inc.includeFile(ln, file, chain)...)
+		}
+	}
+	return arr
+}
+
+// includeFile reads a file, included by the directive `ln`, and returns its lines
+// with any includes expanded.
+func (inc *includer) includeFile(ln line, file string, chain []string) []line {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		inc.merr.Append(includeError(ln, file, chain, err))
+		return nil
+	}
+	for _, f := range chain {
+		if f == abs {
+			inc.merr.Append(ln.errorf(1, "include cycle: %s -> %s", strings.Join(chain, " -> "), abs))
+			return nil
+		}
+	}
+
+	inc.addFile(abs)
+	data, err := ioutil.ReadFile(abs)
+	if err != nil {
+		inc.merr.Append(includeError(ln, file, chain, err))
+		return nil
+	}
+	return inc.expand(buildLines(string(data), abs, inc.opts), abs, chain)
+}
+
+// addFile records an included file, ignoring duplicates.
+func (inc *includer) addFile(abs string) {
+	for _, f := range inc.files {
+		if f == abs {
+			return
+		}
+	}
+	inc.files = append(inc.files, abs)
+}
+
+// includeError creates an error for the failed include directive `ln`, reporting the
+// chain of includes.
+func includeError(ln line, path string, chain []string, err error) error {
+	if len(chain) == 0 {
+		return ln.errorf(1, "error including '%s': %v", path, err)
+	}
+	return ln.errorf(1, "error including '%s' from %s: %v", path, strings.Join(chain, " -> "), err)
+}
+
+// parseInclude parses the specified string for an include directive, which is either
+// `!include path` or `include = path`. Returns the path, or `ok=false` if `str` is not
+// an include directive.
+func parseInclude(str string) (path string, ok bool) {
+	if strings.HasPrefix(str, "!include") {
+		path = strings.TrimSpace(str[len("!include"):])
+		return path, path != ""
+	}
+	iEqPos := strings.Index(str, "=")
+	if iEqPos == -1 || strings.TrimSpace(str[:iEqPos]) != "include" {
+		return "", false
+	}
+	path = strings.TrimSpace(str[iEqPos+1:])
+	return path, path != ""
+}
+
 // parseSection parses the specified string for a section name enclosed in square brackets.
 // Returns the section name found, or `ok=false` if `str` is not a section header.
 func parseSection(str string) (name string, ok bool) {
@@ -107,15 +397,25 @@ func parseSection(str string) (name string, ok bool) {
 	return strings.TrimSpace(str[1:iCloser]), true
 }
 
+// propError describes a problem parsing a property, and the 1-based
+// column where it was found.
+type propError struct {
+	column int
+	msg    string
+}
+
 // parseProp parses the specified string and extracts a key/value pair.
 //
 // If the string is a comment (prefixed with ';' or '#') then `comment=true`
 // and key will be empty.
-func parseProp(str string) (key string, val string, comment bool, err error) {
+//
+// Values enclosed in `"""` have the delimiters removed, but are otherwise
+// returned verbatim. Other values are processed by `parseValue`.
+func parseProp(str string, opts parseOptions) (key string, val string, comment bool, err *propError) {
 	iLen := len(str)
 	iEqPos := strings.Index(str, "=")
 	if iEqPos == -1 {
-		return "", "", false, fmt.Errorf("not a key/value pair:'%s'", str)
+		return "", "", false, &propError{column: 1, msg: "not a key/value pair"}
 	}
 
 	key = str[0:iEqPos]
@@ -127,16 +427,88 @@ func parseProp(str string) (key string, val string, comment bool, err error) {
 
 	// Check that the key has at least 1 char.
 	if key == "" {
-		return "", "", false, fmt.Errorf("key is empty for '%s'", str)
+		return "", "", false, &propError{column: iEqPos + 1, msg: "key is empty"}
 	}
 
 	// Check if this line is a comment that just happens
 	// to have an equals sign in it. Not an error, but not a
 	// useable line either.
-	if strings.HasPrefix(key, ";") || strings.HasPrefix(key, "#") {
-		key = ""
-		val = ""
-		comment = true
+	if isComment(key) {
+		return "", "", true, nil
+	}
+
+	if strings.HasPrefix(val, tripleQuote) {
+		column := strings.Index(str, tripleQuote) + 1
+		end := strings.Index(val[len(tripleQuote):], tripleQuote)
+		if end == -1 {
+			return "", "", false, &propError{column: column, msg: "unterminated triple-quoted value"}
+		}
+		end += len(tripleQuote)
+		rest := strings.TrimSpace(val[end+len(tripleQuote):])
+		if rest != "" && !(opts.stripInlineComments && isComment(rest)) {
+			return "", "", false, &propError{column: column, msg: "unexpected text after triple-quoted value"}
+		}
+		return key, val[len(tripleQuote):end], false, nil
+	}
+	return key, parseValue(val, opts), false, nil
+}
+
+// parseValue processes a value with surrounding whitespace removed.
+//
+// Values enclosed in double quotes have the quotes removed and escape sequences
+// (e.g. `\n`, `\t`, `\"`, `\\`, `\u00e9`) replaced, preserving any whitespace
+// within the quotes. Values that begin with a double quote but are not a valid
+// quoted string are returned as is.
+//
+// If `opts.stripInlineComments` is true then anything following a ';' or '#'
+// preceded by whitespace, and not within quotes, is removed.
+func parseValue(val string, opts parseOptions) string {
+	if strings.HasPrefix(val, `"`) {
+		if s, rest, ok := unquote(val); ok {
+			rest = strings.TrimSpace(rest)
+			if rest == "" || (opts.stripInlineComments && isComment(rest)) {
+				return s
+			}
+		}
+	}
+	if opts.stripInlineComments {
+		val = stripInlineComment(val)
+	}
+	return val
+}
+
+// unquote parses the double-quoted string at the start of `str`, returning
+// the unescaped contents and the remainder of `str` following the closing
+// quote, or `ok=false` if the quoted string is unterminated or contains an
+// invalid escape sequence.
+func unquote(str string) (s string, rest string, ok bool) {
+	var sb strings.Builder
+	str = str[1:]
+	for len(str) > 0 {
+		if str[0] == '"' {
+			return sb.String(), str[1:], true
+		}
+		r, multibyte, tail, err := strconv.UnquoteChar(str, '"')
+		if err != nil {
+			return "", "", false
+		}
+		if r < utf8.RuneSelf || !multibyte {
+			sb.WriteByte(byte(r))
+		} else {
+			sb.WriteRune(r)
+		}
+		str = tail
+	}
+	return "", "", false
+}
+
+// stripInlineComment removes a trailing comment, which begins with ';' or '#'
+// at the start of the value or preceded by whitespace.
+func stripInlineComment(val string) string {
+	for i := 0; i < len(val); i++ {
+		if (val[i] == ';' || val[i] == '#') && (i == 0 || val[i-1] == ' ' || val[i-1] == '\t') {
+			return strings.TrimSpace(val[:i])
+		}
 	}
-	return key, val, comment, err
+	return val
 }
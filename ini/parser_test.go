@@ -3,6 +3,8 @@ package ini
 import (
 	"reflect"
 	"testing"
+
+	"github.com/wiggin77/merror"
 )
 
 func Test_buildLineArray(t *testing.T) {
@@ -84,7 +86,7 @@ func Test_parseProp(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotKey, gotVal, gotComment, err := parseProp(tt.args.str)
+			gotKey, gotVal, gotComment, err := parseProp(tt.args.str, parseOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("parseProp() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -125,7 +127,7 @@ func Test_getSections(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := getSections(tt.args.str)
+			got, _, err := getSections(tt.args.str, "", parseOptions{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getSections() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -136,3 +138,156 @@ func Test_getSections(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseInclude(t *testing.T) {
+	tests := []struct {
+		name     string
+		str      string
+		wantPath string
+		wantOk   bool
+	}{
+		{"1", "!include base.ini", "base.ini", true},
+		{"2", "!include   conf.d/*.ini ", "conf.d/*.ini", true},
+		{"3", "include = base.ini", "base.ini", true},
+		{"4", "include=base.ini", "base.ini", true},
+		{"5", "!include", "", false},
+		{"6", "include =", "", false},
+		{"7", "includes = base.ini", "", false},
+		{"8", "key = include", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPath, gotOk := parseInclude(tt.str)
+			if gotPath != tt.wantPath || gotOk != tt.wantOk {
+				t.Errorf("parseInclude() = %v, %v; want %v, %v", gotPath, gotOk, tt.wantPath, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_getSectionsParseErrors(t *testing.T) {
+	str := "# comment\nprop1=1\n\n[sec1]\n  blap!\nprop2=2\r\n\t = 77\n"
+	_, _, err := getSections(str, "test.ini", parseOptions{})
+	merr, ok := err.(*merror.MError)
+	if !ok {
+		t.Fatalf("getSections() expected *merror.MError, got %T: %v", err, err)
+	}
+
+	want := []*ParseError{
+		{File: "test.ini", Line: 5, Column: 3, Text: "blap!", Msg: "not a key/value pair"},
+		{File: "test.ini", Line: 7, Column: 3, Text: "= 77", Msg: "key is empty"},
+	}
+	got := make([]*ParseError, 0)
+	for _, e := range merr.Errors() {
+		pe, ok := e.(*ParseError)
+		if !ok {
+			t.Fatalf("getSections() expected *ParseError, got %T: %v", e, e)
+		}
+		got = append(got, pe)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getSections() errors = %v, want %v", got, want)
+	}
+
+	if s := want[0].Error(); s != "test.ini:5:3: not a key/value pair: 'blap!'" {
+		t.Errorf("ParseError.Error() = %s", s)
+	}
+}
+
+func Test_getSectionsMultiLine(t *testing.T) {
+	tests := []struct {
+		name string
+		join bool
+		str  string
+		want map[string]string
+	}{
+		{"backslash", true, "sql = select * \\\n   from t\nnext=1", map[string]string{"sql": "select * from t", "next": "1"}},
+		{"backslash multiple", true, "a = 1,\\\n2,\\\n  3", map[string]string{"a": "1,2,3"}},
+		{"double backslash", true, "path = c:\\\\\nnext=1", map[string]string{"path": "c:\\\\", "next": "1"}},
+		{"indented empty key", true, "cert =\n  line 1\n  a = b\n\tline 3\nnext=1", map[string]string{"cert": "line 1\na = b\nline 3", "next": "1"}},
+		{"indented", true, "hosts = a\n  b\n  c\n\n  d=1", map[string]string{"hosts": "a\nb\nc", "d": "1"}},
+		{"indented prop", true, "a=1\n  b=2", map[string]string{"a": "1", "b": "2"}},
+		{"indented comment", true, "a=1\n  #b\n  c", map[string]string{"a": "1\nc"}},
+		{"indented section", true, "a=1\n  [sec]\n  b=2", map[string]string{"a": "1", "sec.b": "2"}},
+		{"backslash not joined", false, "dir = C:\\temp\\\nname = x", map[string]string{"dir": "C:\\temp\\", "name": "x"}},
+		{"indented not joined", false, "key1 =\n  key2 = b", map[string]string{"key1": "", "key2": "b"}},
+		{"triple quoted", false, "json = \"\"\"\n{\n  \"a\": 1\n\n# not a comment\n}\n\"\"\"\nnext=1", map[string]string{"json": "{\n  \"a\": 1\n\n# not a comment\n}", "next": "1"}},
+		{"triple quoted inline", false, "a = \"\"\"first\n second\nthird\"\"\"", map[string]string{"a": "first\n second\nthird"}},
+		{"triple quoted one line", false, "a = \"\"\" x \"\"\"", map[string]string{"a": " x "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, _, err := getSections(tt.str, "", parseOptions{joinContinuations: tt.join})
+			if err != nil {
+				t.Fatalf("getSections() error = %v", err)
+			}
+			got := make(map[string]string)
+			for name, sec := range sections {
+				for k, v := range sec.props {
+					if name != "" {
+						k = name + "." + k
+					}
+					got[k] = v
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getSections() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getSectionsMultiLineErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		str  string
+		want string
+	}{
+		{"unterminated", "a=1\nb = \"\"\"\nline\n", "<string>:2:5: unterminated triple-quoted value: 'b = \"\"\"'"},
+		{"trailing text", "b = \"\"\"\nline\n\"\"\" x\n", "<string>:1:5: unexpected text after triple-quoted value: 'b = \"\"\"line\"\"\" x'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := getSections(tt.str, "", parseOptions{})
+			merr, ok := err.(*merror.MError)
+			if !ok {
+				t.Fatalf("getSections() expected *merror.MError, got %v", err)
+			}
+			if got := merr.Errors()[0].Error(); got != tt.want {
+				t.Errorf("getSections() error = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		val   string
+		strip bool
+		want  string
+	}{
+		{"plain", "value", false, "value"},
+		{"quoted", `"  padded "`, false, "  padded "},
+		{"escapes", `"a\tb\nc\"d\\e\u00e9"`, false, "a\tb\nc\"d\\eé"},
+		{"leading hash", `"#hash"`, true, "#hash"},
+		{"unterminated", `"abc`, false, `"abc`},
+		{"bad escape", `"c:\dir"`, false, `"c:\dir"`},
+		{"text after quote", `"a" b`, false, `"a" b`},
+		{"comment kept", "value ; comment", false, "value ; comment"},
+		{"comment", "value ; comment", true, "value"},
+		{"hash comment", "value\t# comment", true, "value"},
+		{"only comment", "; comment", true, ""},
+		{"no space", "http://host/#frag;x", true, "http://host/#frag;x"},
+		{"quoted comment", `"a ; b" ; comment`, true, "a ; b"},
+		{"quoted no strip", `"a" ; comment`, false, `"a" ; comment`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseValue(tt.val, parseOptions{stripInlineComments: tt.strip})
+			if got != tt.want {
+				t.Errorf("parseValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
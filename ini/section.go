@@ -2,6 +2,7 @@ package ini
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -93,17 +94,18 @@ func (sec *Section) String() string {
 }
 
 // StringPropsOnly returns a string representation of this section
-// without the section header.
+// without the section header. Keys are sorted, and values quoted
+// where needed (see `QuoteValue`).
 func (sec *Section) StringPropsOnly() string {
+	keys := sec.getKeys()
+	sort.Strings(keys)
+
 	sec.mtx.RLock()
 	defer sec.mtx.RUnlock()
 	sb := &strings.Builder{}
 
-	for k, v := range sec.props {
-		sb.WriteString(k)
-		sb.WriteString("=")
-		sb.WriteString(v)
-		sb.WriteString("\n")
+	for _, k := range keys {
+		writeProp(sb, k, sec.props[k])
 	}
 	return sb.String()
 }
@@ -0,0 +1,106 @@
+package ini
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QuoteValue returns the value formatted for writing to an INI file, such that
+// parsing it returns the original value. Values containing leading or trailing
+// whitespace, line breaks, or characters that could be mistaken for a comment,
+// quotes or a line continuation are enclosed in double quotes and escaped.
+func QuoteValue(val string) string {
+	if needsQuotes(val) {
+		return strconv.Quote(val)
+	}
+	return val
+}
+
+// needsQuotes returns true if the value cannot be written verbatim.
+func needsQuotes(val string) bool {
+	if val == "" {
+		return false
+	}
+	if val != strings.TrimSpace(val) || strings.ContainsAny(val, "\r\n") {
+		return true
+	}
+	if strings.HasPrefix(val, `"`) || isComment(val) || strings.HasSuffix(val, `\`) {
+		return true
+	}
+	if stripInlineComment(val) != val {
+		return true
+	}
+	for _, r := range val {
+		if !strconv.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidKey returns true if `key` can be written as the key of a property such
+// that parsing it returns the same key, rather than the line being mistaken for
+// an include directive, section header or comment.
+func ValidKey(key string) bool {
+	if key == "" || key != strings.TrimSpace(key) || strings.ContainsAny(key, "=\r\n") {
+		return false
+	}
+	if key == "include" || strings.HasPrefix(key, "!include") {
+		return false
+	}
+	return !strings.HasPrefix(key, "[") && !isComment(key)
+}
+
+// ValidSectionName returns true if `name` can be written as a section header
+// such that parsing it returns the same name.
+func ValidSectionName(name string) bool {
+	return name != "" && name == strings.TrimSpace(name) && !strings.ContainsAny(name, "]\r\n")
+}
+
+// writeProp writes a single `key = value` line.
+func writeProp(sb *strings.Builder, key string, val string) {
+	sb.WriteString(key)
+	sb.WriteString(" = ")
+	sb.WriteString(QuoteValue(val))
+	sb.WriteString("\n")
+}
+
+// WriteTo writes the INI to `w` in a deterministic order, with sections
+// and keys sorted by name. Properties not in a named section are written
+// first. Comments and formatting of the original file are not preserved;
+// see `Document` for editing files.
+func (ini *Ini) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, ini.String())
+	return int64(n), err
+}
+
+// String returns the INI formatted as written by `WriteTo`.
+func (ini *Ini) String() string {
+	ini.mutex.RLock()
+	defer ini.mutex.RUnlock()
+
+	names := make([]string, 0, len(ini.m))
+	for name := range ini.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sb := &strings.Builder{}
+	for _, name := range names {
+		sec := ini.m[name]
+		if !sec.hasKeys() && name == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		if name == "" {
+			sb.WriteString(sec.StringPropsOnly())
+		} else {
+			sb.WriteString(sec.String())
+		}
+	}
+	return sb.String()
+}
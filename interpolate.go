@@ -0,0 +1,146 @@
+package cfg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix is the reference prefix used to look up environment variables.
+const envPrefix = "env:"
+
+// interpolator expands references within property values.
+// See `Config.SetWantInterpolation` for the supported syntax.
+// Defaults may themselves contain references.
+type interpolator struct {
+	props map[string]string
+	cache map[string]string
+	env   map[string]bool // properties referencing environment variables, directly or not
+	stack []string
+}
+
+// newInterpolator creates an interpolator which resolves references
+// using the specified map of raw property values.
+func newInterpolator(props map[string]string) *interpolator {
+	return &interpolator{props: props, cache: make(map[string]string), env: make(map[string]bool)}
+}
+
+// resolve returns the expanded value of the named property.
+func (ip *interpolator) resolve(name string) (string, bool, error) {
+	if val, ok := ip.cache[name]; ok {
+		if ip.env[name] {
+			ip.markEnv()
+		}
+		return val, true, nil
+	}
+	raw, ok := ip.props[name]
+	if !ok {
+		return "", false, nil
+	}
+
+	for _, n := range ip.stack {
+		if n == name {
+			chain := append(append([]string{}, ip.stack...), name)
+			return "", true, fmt.Errorf("interpolation cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	ip.stack = append(ip.stack, name)
+	val, err := ip.expand(raw)
+	ip.stack = ip.stack[:len(ip.stack)-1]
+	if err != nil {
+		return "", true, err
+	}
+	ip.cache[name] = val
+	return val, true, nil
+}
+
+// expand replaces all references within a string.
+func (ip *interpolator) expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	sb := &strings.Builder{}
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			sb.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		end := matchingBrace(s, i+2)
+		if end == -1 {
+			return "", fmt.Errorf("unterminated reference in '%s'", s)
+		}
+		val, err := ip.reference(s[i+2 : end])
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(val)
+		i = end + 1
+	}
+	return sb.String(), nil
+}
+
+// reference resolves the contents of a single `${...}` reference.
+func (ip *interpolator) reference(ref string) (string, error) {
+	name, def, hasDef := ref, "", false
+	if iDef := strings.Index(ref, ":-"); iDef != -1 {
+		name, def, hasDef = ref[:iDef], ref[iDef+2:], true
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("empty reference '${%s}'", ref)
+	}
+
+	var val string
+	var ok bool
+	var err error
+	if strings.HasPrefix(name, envPrefix) {
+		ip.markEnv()
+		val, ok = os.LookupEnv(name[len(envPrefix):])
+	} else if val, ok, err = ip.resolve(name); err != nil {
+		return "", err
+	}
+
+	if ok {
+		return val, nil
+	}
+	if hasDef {
+		return ip.expand(def)
+	}
+	return "", fmt.Errorf("undefined reference '${%s}'", name)
+}
+
+// markEnv records that the properties being resolved reference
+// environment variables.
+func (ip *interpolator) markEnv() {
+	for _, n := range ip.stack {
+		ip.env[n] = true
+	}
+}
+
+// matchingBrace returns the index of the '}' closing a reference whose
+// contents begin at `start`, accounting for nested references, or -1.
+func matchingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
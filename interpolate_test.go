@@ -0,0 +1,158 @@
+package cfg
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfig_Interpolation(t *testing.T) {
+	os.Setenv("CFG_TEST_HOME", "/home/test")
+	defer os.Unsetenv("CFG_TEST_HOME")
+
+	low := NewSrcMapFromMap(map[string]string{
+		"server.host": "localhost",
+		"server.port": "8080",
+	})
+	high := NewSrcMapFromMap(map[string]string{
+		"url":       "http://${server.host}:${server.port}/api",
+		"home":      "${env:CFG_TEST_HOME}/app",
+		"nohome":    "${env:CFG_TEST_MISSING:-/tmp}",
+		"fallback":  "${missing:-${server.host}}",
+		"escaped":   "$${server.host} costs $5",
+		"nested":    "${url}/v1",
+		"undefined": "${missing}",
+		"cycle1":    "${cycle2}",
+		"cycle2":    "${cycle1}",
+		"self":      "x${self}",
+		"bad":       "${server.host",
+	})
+
+	config := &Config{}
+	defer config.Shutdown()
+	config.SetWantInterpolation(true)
+	config.AppendSource(high, low)
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{"url", "http://localhost:8080/api", ""},
+		{"home", "/home/test/app", ""},
+		{"nohome", "/tmp", ""},
+		{"fallback", "localhost", ""},
+		{"escaped", "${server.host} costs $5", ""},
+		{"nested", "http://localhost:8080/api/v1", ""},
+		{"undefined", "def", "undefined reference"},
+		{"cycle1", "def", "interpolation cycle: cycle1 -> cycle2 -> cycle1"},
+		{"self", "def", "interpolation cycle: self -> self"},
+		{"bad", "def", "unterminated reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.String(tt.name, "def")
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("String(%s); expected err=nil, got err=%v", tt.name, err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("String(%s); expected err containing %q, got err=%v", tt.name, tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("String(%s); expected %q, got %q", tt.name, tt.want, got)
+			}
+		})
+	}
+
+	// referenced keys changing re-evaluates dependent keys.
+	var changed []string
+	config.WatchPrefix("", func(chg PropChange) { changed = append(changed, chg.Name) })
+	config.PrependSource(NewSrcMapFromMap(map[string]string{"server.port": "9090"}))
+
+	want := "nested,server.port,url"
+	if got := strings.Join(changed, ","); got != want {
+		t.Errorf("changes after prepend; expected %s, got %s", want, got)
+	}
+	if url, _ := config.String("url", ""); url != "http://localhost:9090/api" {
+		t.Errorf("url after prepend; got %s", url)
+	}
+
+	// disabled by default
+	plain := &Config{}
+	defer plain.Shutdown()
+	plain.AppendSource(high)
+	if url, _ := plain.String("url", ""); url != "http://${server.host}:${server.port}/api" {
+		t.Errorf("url without interpolation; got %s", url)
+	}
+}
+
+func TestConfig_SetWantInterpolationNotifies(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(NewSrcMapFromMap(map[string]string{
+		"host": "localhost",
+		"url":  "http://${host}",
+	}))
+
+	notify := &NotifyProps{}
+	config.AddChangedListener(notify)
+	changed := make(chan PropChange, 10)
+	config.Watch("url", func(chg PropChange) { changed <- chg })
+
+	config.SetWantInterpolation(true)
+	want := []PropChange{{Name: "url", Type: PropModified, OldVal: "http://${host}", NewVal: "http://localhost"}}
+	if got := notify.getChanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes after SetWantInterpolation = %v, want %v", got, want)
+	}
+	select {
+	case chg := <-changed:
+		if chg.NewVal != "http://localhost" {
+			t.Errorf("Watch; expected url=http://localhost, got %v", chg)
+		}
+	default:
+		t.Error("Watch not notified after SetWantInterpolation")
+	}
+
+	// no changes, no notification
+	config.SetWantInterpolation(true)
+	if got := notify.getChanges(); len(got) != 1 {
+		t.Errorf("expected no further changes, got %v", got)
+	}
+}
+
+func TestConfig_InterpolationEnvAtLookup(t *testing.T) {
+	os.Setenv("CFG_TEST_LOGDIR", "/var/log")
+	defer os.Unsetenv("CFG_TEST_LOGDIR")
+
+	config := &Config{}
+	defer config.Shutdown()
+	config.SetWantInterpolation(true)
+	config.AppendSource(NewSrcMapFromMap(map[string]string{
+		"logdir":  "${env:CFG_TEST_LOGDIR}/app",
+		"logfile": "${logdir}/app.log",
+		"name":    "app",
+	}))
+
+	if val, _ := config.String("logfile", ""); val != "/var/log/app/app.log" {
+		t.Errorf("String(logfile); expected /var/log/app/app.log, got %s", val)
+	}
+
+	os.Setenv("CFG_TEST_LOGDIR", "/tmp")
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"logdir", "/tmp/app"},
+		{"logfile", "/tmp/app/app.log"},
+		{"name", "app"},
+	}
+	for _, tt := range tests {
+		if got, err := config.String(tt.name, ""); err != nil || got != tt.want {
+			t.Errorf("String(%s) after env change; expected %s, got %s (err=%v)", tt.name, tt.want, got, err)
+		}
+	}
+	if info, _ := config.Lookup("logfile"); info.Effective != "/tmp/app/app.log" {
+		t.Errorf("Lookup(logfile) after env change; expected /tmp/app/app.log, got %s", info.Effective)
+	}
+}
@@ -9,3 +9,78 @@ type ChangedListener interface {
 	// changed value.
 	ConfigChanged(cfg *Config, src SourceMonitored)
 }
+
+// ChangedPropsListener is an optional extension of `ChangedListener` for
+// receiving detailed notifications listing each property that was added,
+// removed or modified.
+//
+// Changes are calculated using the effective value of each property, meaning
+// the value resolved by searching all sources in order. A change to a property
+// that is shadowed by a higher priority source does not generate a notification.
+//
+// Listeners implementing this interface are registered via
+// `Config.AddChangedListener` like any other `ChangedListener`, and will have
+// `PropsChanged` called instead of `ConfigChanged`.
+type ChangedPropsListener interface {
+	ChangedListener
+
+	// PropsChanged is called when the effective value of one or more properties
+	// has changed, because `src` was reloaded, added to the config, or removed
+	// from the config. `src` is nil when the change is caused by setting an
+	// option on the config, such as `SetWantInterpolation`. `changes` is sorted
+	// by property name and is never empty.
+	PropsChanged(cfg *Config, src Source, changes []PropChange)
+}
+
+// ErrorListener is an optional extension of `ChangedListener` for receiving
+// notifications of errors involving a source.
+//
+// Listeners implementing this interface are registered via
+// `Config.AddChangedListener` like any other `ChangedListener`.
+type ErrorListener interface {
+	ChangedListener
+
+	// ConfigError is called when an error involving `src` occurs, such as
+	// `GetProps` or `GetLastModified` returning an error, or a listener
+	// panicking while being notified of changes. `err` is a `*SourceError`
+	// describing the operation that failed.
+	//
+	// When `GetProps` fails, depending on the source, the properties
+	// previously fetched may have been retained or replaced by partially
+	// loaded properties. See `Source.GetProps`.
+	ConfigError(cfg *Config, src Source, err error)
+}
+
+// ChangeType describes how a property changed.
+type ChangeType int
+
+const (
+	// PropAdded means the property did not previously exist.
+	PropAdded ChangeType = iota
+	// PropRemoved means the property no longer exists.
+	PropRemoved
+	// PropModified means the property exists but its value changed.
+	PropModified
+)
+
+// String returns a string representation of the `ChangeType`.
+func (ct ChangeType) String() string {
+	switch ct {
+	case PropAdded:
+		return "added"
+	case PropRemoved:
+		return "removed"
+	case PropModified:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// PropChange describes a change to a single property. `OldVal` is
+// empty for added properties and `NewVal` is empty for removed properties.
+type PropChange struct {
+	Name   string
+	Type   ChangeType
+	OldVal string
+	NewVal string
+}
@@ -0,0 +1,105 @@
+package cfg
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// PublishFunc is called by `Live` after re-binding a struct. On success `v`
+// is the newly published snapshot and `err` is nil. On failure `v` is the
+// snapshot that remains current and `err` contains the binding errors.
+type PublishFunc func(v interface{}, err error)
+
+// Live holds a struct bound to a `Config` via `Config.Bind`, which is re-bound
+// whenever the effective value of any property changes. Each re-bind creates a
+// new snapshot of the struct which is swapped in atomically, meaning readers
+// calling `Load` never see a partially updated struct and do not need locks.
+type Live struct {
+	noCopy noCopy
+
+	mutex     sync.Mutex
+	config    *Config
+	template  reflect.Value
+	val       atomic.Value
+	onPublish PublishFunc
+}
+
+// NewLive creates a `Live` that binds a copy of the struct pointed to by `v`
+// and publishes the result. The struct pointed to by `v` is used as a template
+// for every subsequent re-bind, meaning any field values it contains act as
+// defaults. `onPublish` may be nil.
+//
+// Call `Close` to stop re-binding.
+func NewLive(config *Config, v interface{}, onPublish PublishFunc) (*Live, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("live requires a non-nil pointer to a struct")
+	}
+
+	live := &Live{config: config, onPublish: onPublish}
+	live.template = reflect.New(rv.Elem().Type()).Elem()
+	live.template.Set(rv.Elem())
+
+	// Register before the initial bind so no change is missed. Any change
+	// notified meanwhile waits for the mutex, then re-binds.
+	live.mutex.Lock()
+	config.AddChangedListener(live)
+	snapshot, err := live.bind()
+	if err == nil {
+		live.val.Store(snapshot)
+	}
+	live.mutex.Unlock()
+
+	if err != nil {
+		_ = config.RemoveChangedListener(live)
+		return nil, err
+	}
+	return live, nil
+}
+
+// Load returns the current snapshot as a pointer to a struct of the same type
+// passed to `NewLive`. The snapshot must be treated as read-only.
+func (live *Live) Load() interface{} {
+	return live.val.Load()
+}
+
+// Close stops re-binding. The current snapshot remains available via `Load`.
+func (live *Live) Close() {
+	_ = live.config.RemoveChangedListener(live)
+}
+
+// ConfigChanged is never called since `Live` implements `ChangedPropsListener`.
+func (live *Live) ConfigChanged(cfg *Config, src SourceMonitored) {
+}
+
+// PropsChanged re-binds the struct and publishes a new snapshot.
+func (live *Live) PropsChanged(cfg *Config, src Source, changes []PropChange) {
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+
+	if live.val.Load() == nil {
+		return // initial bind failed; `NewLive` is removing the listener
+	}
+	snapshot, err := live.bind()
+	if err != nil {
+		snapshot = live.val.Load()
+	} else {
+		live.val.Store(snapshot)
+	}
+
+	if live.onPublish != nil {
+		live.onPublish(snapshot, err)
+	}
+}
+
+// bind creates a new copy of the template and binds it.
+func (live *Live) bind() (interface{}, error) {
+	rv := reflect.New(live.template.Type())
+	rv.Elem().Set(live.template)
+	if err := live.config.Bind(rv.Interface()); err != nil {
+		return nil, err
+	}
+	return rv.Interface(), nil
+}
@@ -0,0 +1,63 @@
+package cfg
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type liveApp struct {
+	Name    string `cfg:"name"`
+	Retries int    `cfg:"retries"`
+}
+
+func TestLive(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+
+	src := NewSrcMapFromMap(map[string]string{"name": "one"})
+	src.SetMonitorFreq(10 * time.Millisecond)
+	config.AppendSource(src)
+
+	var published int32
+	var pubErr atomic.Value
+	live, err := NewLive(config, &liveApp{Retries: 3}, func(v interface{}, err error) {
+		if err != nil {
+			pubErr.Store(err)
+		}
+		atomic.AddInt32(&published, 1)
+	})
+	if err != nil {
+		t.Fatalf("NewLive; expected err=nil, got err=%v", err)
+	}
+	defer live.Close()
+
+	first := live.Load().(*liveApp)
+	if *first != (liveApp{Name: "one", Retries: 3}) {
+		t.Errorf("Live initial snapshot; got %+v", *first)
+	}
+
+	src.Put("name", "two")
+	time.Sleep(100 * time.Millisecond)
+
+	second := live.Load().(*liveApp)
+	if *second != (liveApp{Name: "two", Retries: 3}) {
+		t.Errorf("Live after change; got %+v", *second)
+	}
+	if first.Name != "one" {
+		t.Errorf("Live previous snapshot was modified; got %+v", *first)
+	}
+	if atomic.LoadInt32(&published) != 1 {
+		t.Errorf("Live expected 1 publish, got %d", atomic.LoadInt32(&published))
+	}
+
+	// binding errors keep the current snapshot.
+	src.Put("retries", "bad")
+	time.Sleep(100 * time.Millisecond)
+	if live.Load().(*liveApp) != second {
+		t.Errorf("Live snapshot replaced despite binding error")
+	}
+	if pubErr.Load() == nil {
+		t.Errorf("Live expected publish with error")
+	}
+}
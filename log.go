@@ -0,0 +1,135 @@
+package cfg
+
+import (
+	"strings"
+
+	"github.com/wiggin77/cfg/ini"
+	"github.com/wiggin77/merror"
+)
+
+// Logger is used by `Config` to report activity such as sources being
+// loaded, reloaded and removed, monitoring being paused, and errors.
+// Arguments following `msg` are alternating key/value pairs, following the
+// conventions of `log/slog`, meaning a `*slog.Logger` satisfies this interface.
+// See `NewSlogLogger`.
+//
+// Property values are never logged for properties with names matching a
+// secret pattern. See `Config.SetSecretPatterns`.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
+
+// Redacted replaces the value of secret properties when logging.
+const Redacted = "[REDACTED]"
+
+// DefaultSecretPatterns are the patterns used to identify properties
+// containing secrets when no patterns have been set via `SetSecretPatterns`.
+var DefaultSecretPatterns = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "private_key", "credential"}
+
+// nopLogger is the `Logger` used when none is set.
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, keyvals ...interface{}) {}
+func (nopLogger) Info(msg string, keyvals ...interface{})  {}
+func (nopLogger) Warn(msg string, keyvals ...interface{})  {}
+func (nopLogger) Error(msg string, keyvals ...interface{}) {}
+
+// SetLogger sets the `Logger` used to report activity. By default nothing
+// is logged. Passing nil disables logging.
+func (config *Config) SetLogger(l Logger) {
+	config.mutexLog.Lock()
+	config.logger = l
+	config.mutexLog.Unlock()
+}
+
+// getLogger returns the `Logger` set via `SetLogger`, or a `Logger` that
+// discards everything if none is set.
+func (config *Config) getLogger() Logger {
+	config.mutexLog.RLock()
+	defer config.mutexLog.RUnlock()
+	if config.logger == nil {
+		return nopLogger{}
+	}
+	return config.logger
+}
+
+// SetSecretPatterns sets the patterns identifying properties whose values
+// are replaced with `Redacted` when logging. A property is secret if its name
+// contains any of the patterns, ignoring case. Calling with no patterns
+// disables redaction. By default `DefaultSecretPatterns` are used.
+func (config *Config) SetSecretPatterns(patterns ...string) {
+	arr := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if p != "" {
+			arr = append(arr, strings.ToLower(p))
+		}
+	}
+
+	config.mutexLog.Lock()
+	config.secretPatterns = arr
+	config.mutexLog.Unlock()
+}
+
+// isSecret returns true if the property name matches a secret pattern.
+func (config *Config) isSecret(name string) bool {
+	config.mutexLog.RLock()
+	patterns := config.secretPatterns
+	config.mutexLog.RUnlock()
+	if patterns == nil {
+		patterns = DefaultSecretPatterns
+	}
+
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if strings.Contains(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns `val`, or `Redacted` if the property is secret and
+// `val` is not empty.
+func (config *Config) redact(name string, val string) string {
+	if val != "" && config.isSecret(name) {
+		return Redacted
+	}
+	return val
+}
+
+// redactErr returns `err` with the text of any INI parse errors involving
+// secret properties redacted, since parse errors include the offending line.
+func (config *Config) redactErr(err error) error {
+	switch e := err.(type) {
+	case *ini.ParseError:
+		idx := strings.Index(e.Text, "=")
+		if idx == -1 {
+			return err
+		}
+		key := strings.TrimSpace(e.Text[:idx])
+		if !config.isSecret(key) {
+			return err
+		}
+		pe := *e
+		pe.Text = key + " = " + Redacted
+		return &pe
+	case *merror.MError:
+		merr := merror.New()
+		for _, ei := range e.Errors() {
+			merr.Append(config.redactErr(ei))
+		}
+		return merr
+	}
+	return err
+}
+
+// logChanges logs each property whose effective value changed.
+func (config *Config) logChanges(log Logger, se *sourceEntry, changes []PropChange) {
+	for _, chg := range changes {
+		log.Debug("config property changed", "source", se.String(), "prop", chg.Name, "change", chg.Type.String(),
+			"old", config.redact(chg.Name, chg.OldVal), "new", config.redact(chg.Name, chg.NewVal))
+	}
+}
@@ -0,0 +1,170 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wiggin77/cfg/ini"
+	"github.com/wiggin77/merror"
+)
+
+// recordLogger is a `Logger` that records each entry as a string.
+type recordLogger struct {
+	mutex   sync.Mutex
+	entries []string
+}
+
+func (rl *recordLogger) log(level string, msg string, keyvals ...interface{}) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.entries = append(rl.entries, fmt.Sprintf("%s %s %v", level, msg, keyvals))
+}
+
+func (rl *recordLogger) Debug(msg string, keyvals ...interface{}) { rl.log("DEBUG", msg, keyvals...) }
+func (rl *recordLogger) Info(msg string, keyvals ...interface{})  { rl.log("INFO", msg, keyvals...) }
+func (rl *recordLogger) Warn(msg string, keyvals ...interface{})  { rl.log("WARN", msg, keyvals...) }
+func (rl *recordLogger) Error(msg string, keyvals ...interface{}) { rl.log("ERROR", msg, keyvals...) }
+
+// find returns the entries containing `s`.
+func (rl *recordLogger) find(s string) []string {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	var arr []string
+	for _, e := range rl.entries {
+		if strings.Contains(e, s) {
+			arr = append(arr, e)
+		}
+	}
+	return arr
+}
+
+func TestConfig_Logger(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+	log := &recordLogger{}
+	config.SetLogger(log)
+
+	src := &failingSrc{props: map[string]string{"db.host": "localhost", "db.password": "hunter2"}}
+	src.SetMonitorFreq(5 * time.Millisecond)
+	if err := config.AppendNamedSource("db", src); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.find("config source loaded"); len(got) != 1 || !strings.Contains(got[0], "keys 2") {
+		t.Errorf("expected one load entry with 2 keys, got %v", got)
+	}
+
+	src.mutex.Lock()
+	src.props = map[string]string{"db.host": "remote", "db.password": "swordfish"}
+	src.mutex.Unlock()
+	src.set(nil, nil)
+	time.Sleep(50 * time.Millisecond)
+
+	if got := log.find("config source reloaded"); len(got) == 0 || !strings.Contains(got[0], "changes 2") {
+		t.Errorf("expected reload entry with 2 changes, got %v", got)
+	}
+	if got := log.find("remote"); len(got) != 1 {
+		t.Errorf("expected change to db.host to be logged, got %v", got)
+	}
+	if got := log.find("db.password"); len(got) != 2 || !strings.Contains(got[1], Redacted) {
+		t.Errorf("expected redacted changes to db.password, got %v", got)
+	}
+
+	src.set(errors.New("db down"), nil)
+	time.Sleep(50 * time.Millisecond)
+	if got := log.find("config source error"); len(got) == 0 || !strings.Contains(got[0], "db down") {
+		t.Errorf("expected error entry, got %v", got)
+	}
+
+	src.SetMonitorFreq(0)
+	time.Sleep(50 * time.Millisecond)
+	if got := log.find("config source monitoring paused"); len(got) != 1 {
+		t.Errorf("expected one pause entry, got %v", got)
+	}
+
+	if err := config.RemoveSource("db"); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.find("config source removed"); len(got) != 1 {
+		t.Errorf("expected one remove entry, got %v", got)
+	}
+
+	for _, secret := range []string{"hunter2", "swordfish"} {
+		if got := log.find(secret); len(got) != 0 {
+			t.Errorf("secret %s logged: %v", secret, got)
+		}
+	}
+}
+
+type panicErrorListener struct {
+	panicListener
+}
+
+func (pel panicErrorListener) ConfigError(cfg *Config, src Source, err error) {
+	panic("error listener boom")
+}
+
+func TestConfig_LoggerErrorListenerPanic(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+	log := &recordLogger{}
+	config.SetLogger(log)
+	config.AddChangedListener(panicErrorListener{})
+
+	if err := config.AppendNamedSource("map", NewSrcMapFromMap(map[string]string{"prop1": "1"})); err != nil {
+		t.Fatal(err)
+	}
+	if got := log.find("config error listener panicked"); len(got) != 1 || !strings.Contains(got[0], "error listener boom") {
+		t.Errorf("expected panicking error listener to be logged, got %v", got)
+	}
+}
+
+func TestConfig_isSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		prop     string
+		want     bool
+	}{
+		{name: "default password", patterns: nil, prop: "db.Password", want: true},
+		{name: "default token", patterns: nil, prop: "AUTH_TOKEN", want: true},
+		{name: "default plain", patterns: nil, prop: "db.host", want: false},
+		{name: "custom match", patterns: []string{"PIN"}, prop: "card.pin", want: true},
+		{name: "custom replaces default", patterns: []string{"pin"}, prop: "db.password", want: false},
+		{name: "disabled", patterns: []string{}, prop: "db.password", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{}
+			if tt.patterns != nil {
+				config.SetSecretPatterns(tt.patterns...)
+			}
+			if got := config.isSecret(tt.prop); got != tt.want {
+				t.Errorf("isSecret(%s) = %v, want %v", tt.prop, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_redactErr(t *testing.T) {
+	config := &Config{}
+
+	merr := merror.New()
+	merr.Append(&ini.ParseError{File: "app.ini", Line: 3, Column: 12, Text: `password = "hunter2`, Msg: "unterminated quote"})
+	merr.Append(&ini.ParseError{File: "app.ini", Line: 4, Column: 1, Text: `host = "x`, Msg: "unterminated quote"})
+	merr.Append(errors.New("other"))
+
+	s := config.redactErr(merr).Error()
+	if strings.Contains(s, "hunter2") || !strings.Contains(s, "password = "+Redacted) {
+		t.Errorf("expected password redacted, got %s", s)
+	}
+	if !strings.Contains(s, `host = "x`) || !strings.Contains(s, "other") {
+		t.Errorf("expected other errors unchanged, got %s", s)
+	}
+	if merr.Error() == s {
+		t.Error("original error was modified")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build go1.21
+// +build go1.21
+
+package cfg
+
+import (
+	"log/slog"
+)
+
+// NewSlogLogger returns a `Logger` which writes to `l`, or to the
+// default `slog.Logger` if `l` is nil.
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return l
+}
@@ -0,0 +1,37 @@
+//go:build go1.21
+// +build go1.21
+
+package cfg
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	config := &Config{}
+	config.SetLogger(NewSlogLogger(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+	src := NewSrcMapFromMap(map[string]string{"api_key": "abc123", "region": "us-east"})
+	if err := config.AppendNamedSource("map", src); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `level=INFO msg="config source loaded" source="'map' (*cfg.SrcMap)" index=0 keys=2 changes=2`) {
+		t.Errorf("missing load entry:\n%s", out)
+	}
+	if !strings.Contains(out, "prop=region change=added old=\"\" new=us-east") {
+		t.Errorf("missing change entry:\n%s", out)
+	}
+	if strings.Contains(out, "abc123") || !strings.Contains(out, "prop=api_key change=added old=\"\" new="+Redacted) {
+		t.Errorf("secret not redacted:\n%s", out)
+	}
+
+	if NewSlogLogger(nil) == nil {
+		t.Error("expected default logger")
+	}
+}
@@ -0,0 +1,67 @@
+package cfg
+
+// PropSource identifies a source supplying a value for a property.
+type PropSource struct {
+	// Source is the source supplying the value.
+	Source Source
+	// SourceName is the name of the source, or empty if unnamed.
+	SourceName string
+	// Index is the position of the source within the list of sources,
+	// where 0 is the source checked first.
+	Index int
+	// Value is the value supplied by the source, before interpolation.
+	Value string
+}
+
+// PropInfo describes the effective value of a property and the source that
+// supplied it.
+type PropInfo struct {
+	PropSource
+
+	// Name is the name of the property.
+	Name string
+	// Effective is the value returned by `Config.String`, which differs from
+	// `Value` when interpolation is enabled or whitespace is removed.
+	Effective string
+	// Shadowed contains the values supplied by lower priority sources, which
+	// are ignored, in the order the sources are checked.
+	Shadowed []PropSource
+}
+
+// Lookup returns the effective value of the named property along with the
+// source that supplied it and any values shadowed by lower priority sources.
+// Returns `ErrNotFound` if no source contains the property, or the error
+// interpolating the value, in which case the returned `PropInfo` is still
+// populated.
+func (config *Config) Lookup(name string) (PropInfo, error) {
+	config.mutexSrc.RLock()
+	defer config.mutexSrc.RUnlock()
+	return config.lookup(name)
+}
+
+// lookup returns the `PropInfo` for the named property.
+//
+// Must be called with `mutexSrc` locked.
+func (config *Config) lookup(name string) (PropInfo, error) {
+	info := PropInfo{Name: name}
+	found := false
+	for i, se := range config.srcs {
+		val, ok := se.props[name]
+		if !ok {
+			continue
+		}
+		ps := PropSource{Source: se.src, SourceName: se.name, Index: i, Value: val}
+		if found {
+			info.Shadowed = append(info.Shadowed, ps)
+		} else {
+			info.PropSource = ps
+			found = true
+		}
+	}
+	if !found {
+		return info, ErrNotFound
+	}
+	val, _, err := config.snapshotLocked().value(name)
+	info.Effective = val
+	return info, err
+}
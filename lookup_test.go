@@ -0,0 +1,53 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfig_Lookup(t *testing.T) {
+	src0 := NewSrcMapFromMap(map[string]string{"host": "override", "url": "http://${host}"})
+	src1 := NewSrcMapFromMap(map[string]string{"port": "80"})
+	src2 := NewSrcMapFromMap(map[string]string{"host": " default ", "port": "8080"})
+
+	config := &Config{}
+	config.AppendSource(src0, src1, src2)
+	config.SetWantInterpolation(true)
+
+	tests := []struct {
+		name    string
+		want    PropInfo
+		wantErr error
+	}{
+		{"host", PropInfo{
+			PropSource: PropSource{Source: src0, Index: 0, Value: "override"},
+			Name:       "host",
+			Effective:  "override",
+			Shadowed:   []PropSource{{Source: src2, Index: 2, Value: " default "}},
+		}, nil},
+		{"port", PropInfo{
+			PropSource: PropSource{Source: src1, Index: 1, Value: "80"},
+			Name:       "port",
+			Effective:  "80",
+			Shadowed:   []PropSource{{Source: src2, Index: 2, Value: "8080"}},
+		}, nil},
+		{"url", PropInfo{
+			PropSource: PropSource{Source: src0, Index: 0, Value: "http://${host}"},
+			Name:       "url",
+			Effective:  "http://override",
+		}, nil},
+		{"blap", PropInfo{Name: "blap"}, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.Lookup(tt.name)
+			if err != tt.wantErr {
+				t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lookup() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
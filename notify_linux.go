@@ -0,0 +1,61 @@
+//go:build linux
+// +build linux
+
+package cfg
+
+import (
+	"os"
+	"syscall"
+)
+
+// notifyMask is the set of inotify events that may indicate a change to
+// a file within a watched directory, or to the directory itself.
+const notifyMask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// notifyDirs uses inotify to watch one or more directories, sending on the
+// returned channel whenever anything within them changes, until `done` is
+// closed. Bursts of events are coalesced into a single notification.
+//
+// Directories are watched rather than files so that files being replaced,
+// or symlinks being changed, are detected.
+func notifyDirs(dirs []string, done <-chan struct{}) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	for _, dir := range dirs {
+		if _, err := syscall.InotifyAddWatch(fd, dir, notifyMask); err != nil {
+			syscall.Close(fd)
+			return nil, &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+		}
+	}
+
+	// A non-blocking descriptor is managed by the runtime poller, meaning
+	// closing the file unblocks any pending read.
+	file := os.NewFile(uintptr(fd), "inotify")
+	events := make(chan struct{}, 1)
+
+	go func() {
+		<-done
+		file.Close()
+	}()
+
+	go func() {
+		defer close(events)
+		buf := make([]byte, (syscall.SizeofInotifyEvent+syscall.NAME_MAX+1)*16)
+		for {
+			n, err := file.Read(buf)
+			if err != nil || n <= 0 {
+				return
+			}
+			select {
+			case events <- struct{}{}:
+			default:
+				// notification already pending
+			}
+		}
+	}()
+	return events, nil
+}
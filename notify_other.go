@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package cfg
+
+import (
+	"errors"
+)
+
+// notifyDirs is not supported on this platform, meaning sources
+// are polled for changes.
+func notifyDirs(dirs []string, done <-chan struct{}) (<-chan struct{}, error) {
+	return nil, errors.New("change notification not supported")
+}
@@ -10,6 +10,11 @@ type Source interface {
 
 	// GetProps fetches all the properties from a source and returns
 	// them as a map.
+	//
+	// If an error is returned then `Config` retains the properties
+	// previously fetched from the source, unless the error is a
+	// `*PartialLoadError` in which case `Config` uses the returned map.
+	// In both cases the error is reported to any `ErrorListener`.
 	GetProps() (map[string]string, error)
 }
 
@@ -34,6 +39,21 @@ type SourceMonitored interface {
 	GetMonitorFreq() time.Duration
 }
 
+// SourceNotifier is an optional interface for monitored config sources that
+// can push notifications when they may have changed, instead of being polled.
+type SourceNotifier interface {
+	SourceMonitored
+
+	// Notify starts watching the source for changes until `done` is closed.
+	// A value is sent on the returned channel whenever the source may have
+	// changed, after which `GetLastModified` is called to determine whether
+	// it actually changed.
+	//
+	// If an error is returned, or the returned channel is closed, the source
+	// is polled at the frequency returned by `GetMonitorFreq` instead.
+	Notify(done <-chan struct{}) (<-chan struct{}, error)
+}
+
 // AbstractSourceMonitor can be embedded in a custom `Source` to provide the
 // basic plumbing for monitor frequency.
 type AbstractSourceMonitor struct {
@@ -0,0 +1,163 @@
+package cfg
+
+import (
+	"errors"
+)
+
+// ErrSourceExists returned when adding a named source and a source
+// with the same name already exists.
+var ErrSourceExists = errors.New("source already exists")
+
+// SourceInfo describes a source within the list of sources.
+type SourceInfo struct {
+	// Name is the name of the source, or empty if added via
+	// `AppendSource` or `PrependSource`.
+	Name   string
+	Source Source
+}
+
+// AppendNamedSource appends a `Source` at the end of the list of sources,
+// such that it is checked last when resolving a property value. The name
+// can be used to remove, replace, or insert sources relative to this one.
+// Returns `ErrSourceExists` if a source with the same name exists.
+func (config *Config) AppendNamedSource(name string, src Source) error {
+	return config.addNamedSource(name, src, config.end)
+}
+
+// PrependNamedSource inserts a `Source` at the beginning of the list of
+// sources, such that it is checked first when resolving a property value.
+// Returns `ErrSourceExists` if a source with the same name exists.
+func (config *Config) PrependNamedSource(name string, src Source) error {
+	return config.addNamedSource(name, src, func() (int, error) { return 0, nil })
+}
+
+// InsertBefore inserts a `Source` named `newName` immediately before the
+// source named `name`, such that it is checked first of the two.
+// Returns `ErrNotFound` if no source is named `name`, or `ErrSourceExists`
+// if a source named `newName` exists.
+func (config *Config) InsertBefore(name string, newName string, src Source) error {
+	return config.addNamedSource(newName, src, func() (int, error) {
+		idx := config.indexOf(name)
+		if idx == -1 {
+			return 0, ErrNotFound
+		}
+		return idx, nil
+	})
+}
+
+// InsertAfter inserts a `Source` named `newName` immediately after the
+// source named `name`, such that it is checked last of the two.
+// Returns `ErrNotFound` if no source is named `name`, or `ErrSourceExists`
+// if a source named `newName` exists.
+func (config *Config) InsertAfter(name string, newName string, src Source) error {
+	return config.addNamedSource(newName, src, func() (int, error) {
+		idx := config.indexOf(name)
+		if idx == -1 {
+			return 0, ErrNotFound
+		}
+		return idx + 1, nil
+	})
+}
+
+// RemoveSource removes the named source from the list of sources, stopping
+// any monitoring of the source. Listeners are notified of any properties
+// whose effective value changed as a result.
+// Returns `ErrNotFound` if no source has the name.
+func (config *Config) RemoveSource(name string) error {
+	config.mutexSrc.Lock()
+	idx := config.indexOf(name)
+	if idx == -1 {
+		config.mutexSrc.Unlock()
+		return ErrNotFound
+	}
+	se := config.srcs[idx]
+	config.srcs = append(config.srcs[:idx], config.srcs[idx+1:]...)
+	close(se.stop)
+	changes := config.updateEffective()
+	config.mutexSrc.Unlock()
+
+	log := config.getLogger()
+	log.Info("config source removed", "source", se.String(), "changes", len(changes))
+	config.logChanges(log, se, changes)
+	config.onSourceRemoved(se, changes)
+	return nil
+}
+
+// ReplaceSource replaces the named source with `src`, keeping the name and
+// position within the list of sources. Monitoring of the previous source is
+// stopped, and started for `src` if supported. Listeners are notified of any
+// properties whose effective value changed as a result.
+// Returns `ErrNotFound` if no source has the name.
+func (config *Config) ReplaceSource(name string, src Source) error {
+	se, serr := config.wrapNamedSource(name, src)
+
+	config.mutexSrc.Lock()
+	idx := config.indexOf(name)
+	if idx == -1 {
+		config.mutexSrc.Unlock()
+		return ErrNotFound
+	}
+	old := config.srcs[idx]
+	config.srcs[idx] = se
+	close(old.stop)
+	changes := config.updateEffective()
+	config.monitor(se)
+	keys := len(se.props)
+	config.mutexSrc.Unlock()
+
+	config.logLoaded(se, idx, keys, changes)
+	if serr != nil {
+		config.onSourceError(serr)
+	}
+	config.onSourceAdded(se, changes)
+	return nil
+}
+
+// GetSources returns the list of sources, in the order they are checked
+// when resolving a property value.
+func (config *Config) GetSources() []SourceInfo {
+	config.mutexSrc.RLock()
+	defer config.mutexSrc.RUnlock()
+
+	arr := make([]SourceInfo, 0, len(config.srcs))
+	for _, se := range config.srcs {
+		arr = append(arr, SourceInfo{Name: se.name, Source: se.src})
+	}
+	return arr
+}
+
+// GetSource returns the named source, or `ErrNotFound` if no source
+// has the name.
+func (config *Config) GetSource(name string) (Source, error) {
+	config.mutexSrc.RLock()
+	defer config.mutexSrc.RUnlock()
+
+	idx := config.indexOf(name)
+	if idx == -1 {
+		return nil, ErrNotFound
+	}
+	return config.srcs[idx].src, nil
+}
+
+// addNamedSource loads the source and inserts it at the index returned
+// by `where`. See `insertSource`.
+func (config *Config) addNamedSource(name string, src Source, where func() (int, error)) error {
+	se, serr := config.wrapNamedSource(name, src)
+	return config.insertSource(se, serr, where)
+}
+
+// indexOf returns the index of the named source within the list of
+// sources, or -1 if not found. Unnamed sources are never found.
+//
+// Must be called with `mutexSrc` locked.
+func (config *Config) indexOf(name string) int {
+	if name == "" {
+		return -1
+	}
+	for i, se := range config.srcs {
+		if se.name == name {
+			return i
+		}
+	}
+	return -1
+}
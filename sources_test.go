@@ -0,0 +1,108 @@
+package cfg
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func sourceNames(config *Config) []string {
+	arr := make([]string, 0)
+	for _, si := range config.GetSources() {
+		arr = append(arr, si.Name)
+	}
+	return arr
+}
+
+func TestConfig_NamedSources(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+
+	defaults := NewSrcMapFromMap(map[string]string{"prop1": "default", "prop2": "default"})
+	tenant := NewSrcMapFromMap(map[string]string{"prop1": "tenant"})
+
+	if err := config.AppendNamedSource("defaults", defaults); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.PrependNamedSource("tenant", tenant); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.AppendNamedSource("tenant", tenant); err != ErrSourceExists {
+		t.Errorf("AppendNamedSource() error = %v, want %v", err, ErrSourceExists)
+	}
+	config.AppendSource(NewSrcMap())
+	if err := config.InsertAfter("tenant", "env", NewSrcMap()); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InsertBefore("tenant", "flags", NewSrcMap()); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.InsertBefore("blap", "x", NewSrcMap()); err != ErrNotFound {
+		t.Errorf("InsertBefore() error = %v, want %v", err, ErrNotFound)
+	}
+
+	want := []string{"flags", "tenant", "env", "defaults", ""}
+	if got := sourceNames(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSources() = %v, want %v", got, want)
+	}
+	if src, err := config.GetSource("tenant"); err != nil || src != tenant {
+		t.Errorf("GetSource() = %v, %v", src, err)
+	}
+
+	notify := &NotifyProps{}
+	config.AddChangedListener(notify)
+
+	// replace tenant overlay
+	if err := config.ReplaceSource("tenant", NewSrcMapFromMap(map[string]string{"prop2": "tenant2"})); err != nil {
+		t.Fatal(err)
+	}
+	if got := sourceNames(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetSources() after replace = %v, want %v", got, want)
+	}
+	wantChanges := []PropChange{
+		{Name: "prop1", Type: PropModified, OldVal: "tenant", NewVal: "default"},
+		{Name: "prop2", Type: PropModified, OldVal: "default", NewVal: "tenant2"},
+	}
+	if got := notify.getChanges(); !reflect.DeepEqual(got, wantChanges) {
+		t.Errorf("changes after replace = %v, want %v", got, wantChanges)
+	}
+
+	// remove tenant overlay
+	if err := config.RemoveSource("tenant"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.RemoveSource("tenant"); err != ErrNotFound {
+		t.Errorf("RemoveSource() error = %v, want %v", err, ErrNotFound)
+	}
+	wantChanges = append(wantChanges, PropChange{Name: "prop2", Type: PropModified, OldVal: "tenant2", NewVal: "default"})
+	if got := notify.getChanges(); !reflect.DeepEqual(got, wantChanges) {
+		t.Errorf("changes after remove = %v, want %v", got, wantChanges)
+	}
+	if val, _ := config.String("prop2", ""); val != "default" {
+		t.Errorf("expected prop2=default, got %s", val)
+	}
+}
+
+func TestConfig_RemoveSourceStopsMonitor(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+
+	before := runtime.NumGoroutine()
+	src := makeSrc(5 * time.Millisecond)
+	if err := config.AppendNamedSource("src", src); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := config.RemoveSource("src"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("monitor still running after RemoveSource; goroutines=%d, want %d", n, before)
+	}
+}
@@ -0,0 +1,121 @@
+package cfg
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+)
+
+// decodeFunc decodes the contents of a reader into nested maps and slices.
+type decodeFunc func(r io.Reader) (map[string]interface{}, error)
+
+// srcDecoded provides the plumbing for configuration sources backed by
+// a file or reader whose contents are decoded into nested maps and then
+// flattened into dotted property names.
+type srcDecoded struct {
+	AbstractSourceMonitor
+	decode  decodeFunc
+	tracker *fileTracker // nil when loaded from a reader
+	props   map[string]string
+	loaded  time.Time
+}
+
+// loadFromFile reads and decodes the tracked file. See `fileTracker` for
+// how files are re-opened when loaded by filespec.
+func (sd *srcDecoded) loadFromFile(tracker *fileTracker) error {
+	data, lm, err := tracker.read()
+	if err != nil {
+		return err
+	}
+	if err := sd.loadFromReader(bytes.NewReader(data)); err != nil {
+		return err
+	}
+	sd.mutex.Lock()
+	sd.tracker = tracker
+	sd.loaded = lm
+	sd.mutex.Unlock()
+	return nil
+}
+
+// loadFromReader decodes the contents of a reader.
+func (sd *srcDecoded) loadFromReader(reader io.Reader) error {
+	nested, err := sd.decode(reader)
+	if err != nil {
+		return err
+	}
+	props := make(map[string]string)
+	flatten("", nested, props)
+
+	sd.mutex.Lock()
+	sd.props = props
+	sd.mutex.Unlock()
+	return nil
+}
+
+// GetProps fetches all the properties from a source and returns
+// them as a map.
+func (sd *srcDecoded) GetProps() (map[string]string, error) {
+	sd.mutex.RLock()
+	tracker := sd.tracker
+	loaded := sd.loaded
+	sd.mutex.RUnlock()
+
+	if tracker != nil {
+		lm, err := tracker.lastModified()
+		if err != nil {
+			return nil, err
+		}
+
+		// Check if we need to reload.
+		if loaded != lm {
+			if err := sd.loadFromFile(tracker); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
+	return sd.props, nil
+}
+
+// GetLastModified returns the time of the latest modification to any
+// property value within the source. Sources created from a reader
+// return the zero value for `Time` since they are never reloaded.
+func (sd *srcDecoded) GetLastModified() (time.Time, error) {
+	sd.mutex.RLock()
+	tracker := sd.tracker
+	sd.mutex.RUnlock()
+
+	if tracker == nil {
+		return time.Time{}, nil
+	}
+	return tracker.lastModified()
+}
+
+// SetChangeDetection sets the strategy used to detect changes to the file.
+// Defaults to `DetectModTime`. Has no effect for sources created from a reader.
+func (sd *srcDecoded) SetChangeDetection(detect ChangeDetection) {
+	sd.mutex.RLock()
+	tracker := sd.tracker
+	sd.mutex.RUnlock()
+
+	if tracker != nil {
+		tracker.setDetection(detect)
+	}
+}
+
+// Notify starts watching the file for changes until `done` is closed.
+// See `SourceNotifier`. Sources created from a reader do not support
+// notifications.
+func (sd *srcDecoded) Notify(done <-chan struct{}) (<-chan struct{}, error) {
+	sd.mutex.RLock()
+	tracker := sd.tracker
+	sd.mutex.RUnlock()
+
+	if tracker == nil {
+		return nil, errors.New("source is not backed by a file")
+	}
+	return tracker.notify(done)
+}
@@ -0,0 +1,323 @@
+package cfg
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wiggin77/cfg/ini"
+	"github.com/wiggin77/merror"
+)
+
+// DefaultDirPatterns are the file name patterns loaded by `SrcDir`
+// when none are specified.
+var DefaultDirPatterns = []string{"*.conf", "*.ini"}
+
+// dirEntry identifies the state of one file within a directory.
+type dirEntry struct {
+	name    string
+	size    int64
+	modTime time.Time
+	sum     [sha256.Size]byte
+}
+
+// SrcDir is a configuration `Source` backed by a directory of files
+// containing name/value pairs or INI format (conf.d style).
+//
+// Files matching the patterns are loaded in lexical order and merged, with
+// properties in later files overriding the same properties in earlier files.
+// The directory is monitored for files being added, removed or modified, as
+// are any files included by the files in the directory.
+type SrcDir struct {
+	AbstractSourceMonitor
+	dir      string
+	patterns []string
+	detect   ChangeDetection
+	mode     ini.ParseMode
+	seen     []dirEntry // listing as of last call to GetLastModified
+	loaded   []dirEntry // listing as of last load
+	props    map[string]string
+	good     map[string]map[string]string // last good properties of each file
+	incs     []string                     // files included as of last load
+	warn     error                        // errors from a lenient initial load, returned by the next GetProps
+	lm       time.Time
+	rewatch  chan struct{} // closed when the directories to watch change
+}
+
+// NewSrcDir creates a new SrcDir for the specified directory, loading
+// files matching `DefaultDirPatterns`.
+func NewSrcDir(dir string) (*SrcDir, error) {
+	return NewSrcDirWithPatterns(dir, DefaultDirPatterns...)
+}
+
+// NewSrcDirWithPatterns creates a new SrcDir for the specified directory,
+// loading files whose names match any of the patterns. See `filepath.Match`
+// for pattern syntax.
+func NewSrcDirWithPatterns(dir string, patterns ...string) (*SrcDir, error) {
+	return NewSrcDirWithMode(dir, ini.ParseKeepLastGood, patterns...)
+}
+
+// NewSrcDirWithMode creates a new SrcDir for the specified directory and
+// `ini.ParseMode`, loading files whose names match any of the patterns, or
+// `DefaultDirPatterns` if none are specified. Parse errors cause an error to
+// be returned unless `mode` is `ini.ParseLenient`. See `SetParseMode`.
+func NewSrcDirWithMode(dir string, mode ini.ParseMode, patterns ...string) (*SrcDir, error) {
+	if len(patterns) == 0 {
+		patterns = DefaultDirPatterns
+	}
+	sd := &SrcDir{}
+	sd.freq = time.Minute
+	sd.dir = dir
+	sd.patterns = patterns
+	sd.mode = mode
+	sd.lm = time.Now()
+	sd.rewatch = make(chan struct{})
+
+	entries, err := sd.scan()
+	if err != nil {
+		return nil, err
+	}
+	if err := sd.load(entries); err != nil {
+		if mode != ini.ParseLenient {
+			return nil, err
+		}
+		sd.warn = err
+	}
+	sd.seen = entries
+	return sd, nil
+}
+
+// SetParseMode sets how errors loading each file are handled when reloading.
+// Properties from the files that loaded successfully are always used, while
+// for a file that failed to load:
+//
+// * `ini.ParseKeepLastGood` uses the last good properties of the file. This
+// is the default.
+// * `ini.ParseStrict` uses no properties from the file.
+// * `ini.ParseLenient` uses all properties of the file that parsed successfully.
+//
+// In all modes `GetProps` returns a `*PartialLoadError`, meaning `Config`
+// reports the error to any `ErrorListener`.
+func (sd *SrcDir) SetParseMode(mode ini.ParseMode) {
+	sd.mutex.Lock()
+	sd.mode = mode
+	sd.mutex.Unlock()
+}
+
+// GetProps fetches all the properties from a source and returns
+// them as a map. See `SetParseMode` for how errors are handled.
+func (sd *SrcDir) GetProps() (map[string]string, error) {
+	entries, err := sd.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	sd.mutex.Lock()
+	changed := !equalDirEntries(entries, sd.loaded)
+	warn := sd.warn
+	sd.warn = nil
+	sd.mutex.Unlock()
+
+	// Check if we need to reload.
+	if changed {
+		warn = sd.load(entries)
+	}
+
+	sd.mutex.RLock()
+	defer sd.mutex.RUnlock()
+	if warn != nil {
+		return sd.props, &PartialLoadError{Err: warn}
+	}
+	return sd.props, nil
+}
+
+// GetLastModified returns the time of the latest modification to any
+// property value within the source, which is the time a change to the
+// directory listing or to any of the files was first detected.
+func (sd *SrcDir) GetLastModified() (time.Time, error) {
+	entries, err := sd.scan()
+	if err != nil {
+		return time.Now(), err
+	}
+
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+
+	if !equalDirEntries(entries, sd.seen) {
+		sd.seen = entries
+		sd.lm = advance(sd.lm)
+	}
+	return sd.lm, nil
+}
+
+// SetChangeDetection sets the strategy used to detect changes to the files
+// within the directory. Defaults to `DetectModTime`. Files being added or
+// removed are always detected.
+func (sd *SrcDir) SetChangeDetection(detect ChangeDetection) {
+	sd.mutex.Lock()
+	sd.detect = detect
+	sd.mutex.Unlock()
+}
+
+// Notify starts watching the directory, and the directories containing any
+// included files, for changes until `done` is closed. See `SourceNotifier`.
+func (sd *SrcDir) Notify(done <-chan struct{}) (<-chan struct{}, error) {
+	return notifyRewatch(func() ([]string, <-chan struct{}) {
+		sd.mutex.RLock()
+		defer sd.mutex.RUnlock()
+		return sd.dirs(), sd.rewatch
+	}, done)
+}
+
+// dirs returns the directory plus the existing directories containing
+// any included files.
+//
+// Must be called with `mutex` locked.
+func (sd *SrcDir) dirs() []string {
+	dirs := []string{sd.dir}
+	for _, inc := range sd.incs {
+		dir := filepath.Dir(inc)
+		if containsString(dirs, dir) {
+			continue
+		}
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// scan returns the list of matching files within the directory, sorted by
+// name, followed by the files included as of the last load. Included files
+// are listed by absolute path and omitted if missing, since errors including
+// them are reported when loading.
+func (sd *SrcDir) scan() ([]dirEntry, error) {
+	infos, err := ioutil.ReadDir(sd.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sd.mutex.RLock()
+	detect := sd.detect
+	incs := sd.incs
+	sd.mutex.RUnlock()
+
+	entries := make([]dirEntry, 0, len(infos)+len(incs))
+	for _, fi := range infos {
+		if fi.IsDir() || !sd.matches(fi.Name()) {
+			continue
+		}
+		// Follow symlinks so a change to the target is detected.
+		if entry, ok := statEntry(filepath.Join(sd.dir, fi.Name()), fi.Name(), detect); ok {
+			entries = append(entries, entry)
+		}
+	}
+	for _, inc := range incs {
+		if entry, ok := statEntry(inc, inc, detect); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// statEntry returns the state of the file at `filespec`, or false if it
+// does not exist or is a directory.
+func statEntry(filespec string, name string, detect ChangeDetection) (dirEntry, bool) {
+	fi, err := os.Stat(filespec)
+	if err != nil || fi.IsDir() {
+		return dirEntry{}, false // removed since listing
+	}
+	entry := dirEntry{name: name}
+	if detect == DetectContentHash {
+		data, err := ioutil.ReadFile(filespec)
+		if err != nil {
+			return dirEntry{}, false
+		}
+		entry.sum = sha256.Sum256(data)
+	} else {
+		entry.size = fi.Size()
+		entry.modTime = fi.ModTime()
+	}
+	return entry, true
+}
+
+// matches returns true if the file name matches any of the patterns.
+func (sd *SrcDir) matches(name string) bool {
+	for _, pattern := range sd.patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// load loads and merges the listed files in order. Files that fail to load
+// are handled according to the `ini.ParseMode`, and their errors are returned
+// once all the other files are loaded.
+func (sd *SrcDir) load(entries []dirEntry) error {
+	sd.mutex.RLock()
+	mode := sd.mode
+	prevGood := sd.good
+	sd.mutex.RUnlock()
+
+	merr := merror.New()
+	props := make(map[string]string)
+	good := make(map[string]map[string]string)
+	var incs []string
+	for _, entry := range entries {
+		if filepath.IsAbs(entry.name) {
+			continue // included file
+		}
+		var i ini.Ini
+		i.SetParseMode(mode)
+		err := i.LoadFromFilespec(filepath.Join(sd.dir, entry.name))
+		m := i.ToMap()
+		if err == nil {
+			good[entry.name] = m
+		} else {
+			merr.Append(err)
+			if last, ok := prevGood[entry.name]; ok {
+				good[entry.name] = last
+				if mode == ini.ParseKeepLastGood {
+					m = last
+				}
+			}
+		}
+		for k, v := range m {
+			props[k] = v
+		}
+		for _, inc := range i.GetIncludes() {
+			if !containsString(incs, inc) {
+				incs = append(incs, inc)
+			}
+		}
+	}
+
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+	sd.props = props
+	sd.good = good
+	sd.loaded = entries
+	dirs := sd.dirs()
+	sd.incs = incs
+	if !sameStrings(dirs, sd.dirs()) {
+		close(sd.rewatch)
+		sd.rewatch = make(chan struct{})
+	}
+	return merr.ErrorOrNil()
+}
+
+// equalDirEntries returns true if two directory listings are identical.
+func equalDirEntries(a []dirEntry, b []dirEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].name != b[i].name || a[i].size != b[i].size || !a[i].modTime.Equal(b[i].modTime) || a[i].sum != b[i].sum {
+			return false
+		}
+	}
+	return true
+}
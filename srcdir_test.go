@@ -0,0 +1,236 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wiggin77/cfg/ini"
+)
+
+func writeTestFile(t *testing.T, filespec string, data string) {
+	t.Helper()
+	if err := ioutil.WriteFile(filespec, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSrcDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, filepath.Join(dir, "10-base.conf"), "name=base\nretries=3\n[db]\nhost=localhost\n")
+	writeTestFile(t, filepath.Join(dir, "20-override.ini"), "[db]\nhost=remote\n")
+	writeTestFile(t, filepath.Join(dir, "30-ignored.txt"), "name=ignored\n")
+	if err := os.Mkdir(filepath.Join(dir, "40-subdir.conf"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := NewSrcDir(dir)
+	if err != nil {
+		t.Fatalf("NewSrcDir; expected err=nil, got err=%v", err)
+	}
+
+	got, _ := src.GetProps()
+	want := map[string]string{"name": "base", "retries": "3", "db.host": "remote"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() = %v, want %v", got, want)
+	}
+
+	lm, err := src.GetLastModified()
+	if err != nil {
+		t.Errorf("GetLastModified; expected err=nil, got err=%v", err)
+	}
+
+	// add a file
+	writeTestFile(t, filepath.Join(dir, "15-middle.conf"), "retries=5\n")
+	lm2, _ := src.GetLastModified()
+	if !lm.Before(lm2) {
+		t.Errorf("GetLastModified after add; expected %v before %v", lm, lm2)
+	}
+	got, _ = src.GetProps()
+	want = map[string]string{"name": "base", "retries": "5", "db.host": "remote"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() after add = %v, want %v", got, want)
+	}
+
+	// remove a file
+	if err := os.Remove(filepath.Join(dir, "20-override.ini")); err != nil {
+		t.Fatal(err)
+	}
+	lm3, _ := src.GetLastModified()
+	if !lm2.Before(lm3) {
+		t.Errorf("GetLastModified after remove; expected %v before %v", lm2, lm3)
+	}
+	got, _ = src.GetProps()
+	want = map[string]string{"name": "base", "retries": "5", "db.host": "localhost"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() after remove = %v, want %v", got, want)
+	}
+
+	// modify a file
+	filespec := filepath.Join(dir, "10-base.conf")
+	writeTestFile(t, filespec, "name=modified\n")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filespec, future, future); err != nil {
+		t.Fatal(err)
+	}
+	lm4, _ := src.GetLastModified()
+	if !lm3.Before(lm4) {
+		t.Errorf("GetLastModified after modify; expected %v before %v", lm3, lm4)
+	}
+	got, _ = src.GetProps()
+	want = map[string]string{"name": "modified", "retries": "5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() after modify = %v, want %v", got, want)
+	}
+
+	// no change
+	if lm5, _ := src.GetLastModified(); !lm5.Equal(lm4) {
+		t.Errorf("GetLastModified without change; expected %v, got %v", lm4, lm5)
+	}
+
+	if _, err := NewSrcDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("NewSrcDir; expected error for missing directory")
+	}
+}
+
+func TestSrcDir_ContentHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.conf")
+	writeTestFile(t, filespec, "name=one\n")
+
+	src, err := NewSrcDir(dir)
+	if err != nil {
+		t.Fatalf("NewSrcDir; expected err=nil, got err=%v", err)
+	}
+	src.SetChangeDetection(DetectContentHash)
+	lm, _ := src.GetLastModified()
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filespec, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if lm2, _ := src.GetLastModified(); !lm2.Equal(lm) {
+		t.Errorf("GetLastModified after touch; expected %v, got %v", lm, lm2)
+	}
+
+	writeTestFile(t, filespec, "name=two\n")
+	if err := os.Chtimes(filespec, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if lm3, _ := src.GetLastModified(); !lm.Before(lm3) {
+		t.Errorf("GetLastModified after edit; expected %v before %v", lm, lm3)
+	}
+	checkProp(t, src, "name", "two")
+}
+
+func TestSrcDir_ParseModes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	good := filepath.Join(dir, "10-good.conf")
+	bad := filepath.Join(dir, "20-bad.conf")
+	writeTestFile(t, good, "name=good\n")
+	writeTestFile(t, bad, "level=one\n")
+
+	tests := []struct {
+		mode ini.ParseMode
+		want map[string]string
+	}{
+		{mode: ini.ParseKeepLastGood, want: map[string]string{"name": "good", "level": "one"}},
+		{mode: ini.ParseStrict, want: map[string]string{"name": "good"}},
+		{mode: ini.ParseLenient, want: map[string]string{"name": "good", "level": "two"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			writeTestFile(t, bad, "level=one\n")
+			src, err := NewSrcDirWithMode(dir, tt.mode)
+			if err != nil {
+				t.Fatalf("NewSrcDirWithMode; expected err=nil, got err=%v", err)
+			}
+
+			writeTestFile(t, bad, "level=two\nbroken\n")
+			future := time.Now().Add(time.Hour)
+			if err := os.Chtimes(bad, future, future); err != nil {
+				t.Fatal(err)
+			}
+			got, err := src.GetProps()
+			if _, ok := err.(*PartialLoadError); !ok {
+				t.Errorf("GetProps; expected *PartialLoadError, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProps() = %v, want %v", got, tt.want)
+			}
+
+			// errors are reported once per modification.
+			if _, err := src.GetProps(); err != nil {
+				t.Errorf("GetProps without change; expected err=nil, got err=%v", err)
+			}
+		})
+	}
+
+	// only lenient accepts a broken file initially.
+	if _, err := NewSrcDir(dir); err == nil {
+		t.Error("NewSrcDir; expected error")
+	}
+	src, err := NewSrcDirWithMode(dir, ini.ParseLenient)
+	if err != nil {
+		t.Fatalf("NewSrcDirWithMode; expected err=nil, got err=%v", err)
+	}
+	if _, err := src.GetProps(); err == nil {
+		t.Error("GetProps; expected error from initial load")
+	}
+	checkProp(t, src, "level", "two")
+}
+
+func TestSrcDir_Includes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	confd := filepath.Join(dir, "conf.d")
+	if err := os.Mkdir(confd, 0700); err != nil {
+		t.Fatal(err)
+	}
+	shared := filepath.Join(dir, "shared.inc")
+	writeTestFile(t, filepath.Join(confd, "10-app.conf"), "name=app\n!include ../shared.inc\n")
+	writeTestFile(t, shared, "level=one\n")
+
+	src, err := NewSrcDir(confd)
+	if err != nil {
+		t.Fatalf("NewSrcDir; expected err=nil, got err=%v", err)
+	}
+	checkProp(t, src, "level", "one")
+	if dirs := src.dirs(); !reflect.DeepEqual(dirs, []string{confd, dir}) {
+		t.Errorf("dirs() = %v, want %v", dirs, []string{confd, dir})
+	}
+	lm, _ := src.GetLastModified()
+
+	writeTestFile(t, shared, "level=two\n")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(shared, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if lm2, _ := src.GetLastModified(); !lm.Before(lm2) {
+		t.Errorf("GetLastModified after editing include; expected %v before %v", lm, lm2)
+	}
+	checkProp(t, src, "level", "two")
+}
@@ -0,0 +1,92 @@
+package cfg
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// DefaultEnvSeparator is the default separator used by `SrcEnv` to split
+// an environment variable name into section and key.
+const DefaultEnvSeparator = "__"
+
+// SrcEnv is a configuration `Source` backed by environment variables.
+//
+// Only variables whose name starts with the specified prefix are included.
+// The prefix is removed, each occurrence of the separator is replaced with
+// a period, and the result is converted to lower case, such that names line
+// up with the `section.key` names produced for INI files.
+// For example, with prefix `MYAPP_` the variable `MYAPP_DB__HOST` maps to
+// property `db.host`.
+//
+// The environment is read each time `GetProps` is called.
+type SrcEnv struct {
+	mutex     sync.RWMutex
+	prefix    string
+	sep       string
+	lowerCase bool
+	environ   func() []string
+}
+
+// NewSrcEnv creates a `SrcEnv` containing the environment variables
+// starting with `prefix`. An empty prefix includes all environment variables.
+func NewSrcEnv(prefix string) *SrcEnv {
+	se := &SrcEnv{}
+	se.prefix = prefix
+	se.sep = DefaultEnvSeparator
+	se.lowerCase = true
+	se.environ = os.Environ
+	return se
+}
+
+// SetSeparator sets the string which separates section and key within
+// an environment variable name. Defaults to `DefaultEnvSeparator`.
+// An empty separator means no mapping to sections is performed.
+func (se *SrcEnv) SetSeparator(sep string) {
+	se.mutex.Lock()
+	se.sep = sep
+	se.mutex.Unlock()
+}
+
+// SetLowerCase determines whether property names are converted
+// to lower case. Defaults to true.
+func (se *SrcEnv) SetLowerCase(b bool) {
+	se.mutex.Lock()
+	se.lowerCase = b
+	se.mutex.Unlock()
+}
+
+// GetProps fetches all the properties from a source and returns
+// them as a map.
+func (se *SrcEnv) GetProps() (map[string]string, error) {
+	se.mutex.RLock()
+	defer se.mutex.RUnlock()
+
+	m := make(map[string]string)
+	for _, env := range se.environ() {
+		iEq := strings.Index(env, "=")
+		if iEq == -1 {
+			continue
+		}
+		name, val := env[:iEq], env[iEq+1:]
+		if !strings.HasPrefix(name, se.prefix) {
+			continue
+		}
+		if key := se.mapKey(name[len(se.prefix):]); key != "" {
+			m[key] = val
+		}
+	}
+	return m, nil
+}
+
+// mapKey converts an environment variable name, with prefix removed,
+// to a property name.
+func (se *SrcEnv) mapKey(name string) string {
+	if se.sep != "" {
+		name = strings.Replace(name, se.sep, ".", -1)
+	}
+	if se.lowerCase {
+		name = strings.ToLower(name)
+	}
+	return name
+}
@@ -0,0 +1,51 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testEnviron = []string{
+	"HOME=/home/test",
+	"MYAPP_DB__HOST=db.example.com",
+	"MYAPP_DB__POOL_SIZE=10",
+	"MYAPP_NAME=app",
+	"MYAPP_=empty",
+	"MYAPP_EQ=a=b",
+}
+
+func TestSrcEnv_GetProps(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefix    string
+		sep       string
+		lowerCase bool
+		want      map[string]string
+	}{
+		{"default", "MYAPP_", DefaultEnvSeparator, true, map[string]string{
+			"db.host": "db.example.com", "db.pool_size": "10", "name": "app", "eq": "a=b"}},
+		{"no_fold", "MYAPP_", DefaultEnvSeparator, false, map[string]string{
+			"DB.HOST": "db.example.com", "DB.POOL_SIZE": "10", "NAME": "app", "EQ": "a=b"}},
+		{"sep", "MYAPP_", "_", true, map[string]string{
+			"db..host": "db.example.com", "db..pool.size": "10", "name": "app", "eq": "a=b"}},
+		{"no_sep", "MYAPP_DB__", "", true, map[string]string{
+			"host": "db.example.com", "pool_size": "10"}},
+		{"none", "NOPE_", DefaultEnvSeparator, true, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := NewSrcEnv(tt.prefix)
+			src.environ = func() []string { return testEnviron }
+			src.SetSeparator(tt.sep)
+			src.SetLowerCase(tt.lowerCase)
+
+			got, err := src.GetProps()
+			if err != nil {
+				t.Errorf("GetProps() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetProps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package cfg
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/wiggin77/cfg/ini"
@@ -11,53 +13,203 @@ import (
 // name/value pairs or INI format.
 type SrcFile struct {
 	AbstractSourceMonitor
-	ini  ini.Ini
-	file *os.File
+	ini     ini.Ini
+	tracker *fileTracker
+	files   *fileSet // the file plus any files it includes
+	loaded  time.Time
+	warn    error // errors from a lenient initial load, returned by the next GetProps
 }
 
 // NewSrcFileFromFilespec creates a new SrcFile with the specified filespec.
+//
+// The file is re-opened by path whenever it changes, meaning the source
+// survives the file being replaced, such as when a new file is renamed over
+// the old one or a symlink is changed to point to a different file. If the
+// file temporarily disappears the last loaded properties are retained.
 func NewSrcFileFromFilespec(filespec string) (*SrcFile, error) {
-	file, err := os.Open(filespec)
-	if err != nil {
-		return nil, err
+	return NewSrcFileFromFilespecWithMode(filespec, ini.ParseKeepLastGood)
+}
+
+// NewSrcFileFromFilespecWithMode creates a new SrcFile with the specified
+// filespec and `ini.ParseMode`. See `NewSrcFileFromFilespec` and `SetParseMode`.
+func NewSrcFileFromFilespecWithMode(filespec string, mode ini.ParseMode) (*SrcFile, error) {
+	return newSrcFile(newFileTrackerFromFilespec(filespec), mode)
+}
+
+// NewSrcFromFilespec creates a new file based `Source` with the specified
+// filespec, choosing the file format based on the file extension:
+//
+// * ".json" creates a `SrcJSON`
+// * ".yaml", ".yml" creates a `SrcYAML`
+// * ".toml" creates a `SrcTOML`
+// * anything else creates a `SrcFile` for INI or name/value pairs
+func NewSrcFromFilespec(filespec string) (SourceMonitored, error) {
+	var src SourceMonitored
+	var err error
+
+	// Assign via typed variables so a failure returns a nil interface
+	// rather than an interface holding a nil pointer.
+	switch strings.ToLower(filepath.Ext(filespec)) {
+	case ".json":
+		var sj *SrcJSON
+		if sj, err = NewSrcJSONFromFilespec(filespec); err == nil {
+			src = sj
+		}
+	case ".yaml", ".yml":
+		var sy *SrcYAML
+		if sy, err = NewSrcYAMLFromFilespec(filespec); err == nil {
+			src = sy
+		}
+	case ".toml":
+		var st *SrcTOML
+		if st, err = NewSrcTOMLFromFilespec(filespec); err == nil {
+			src = st
+		}
+	default:
+		var sf *SrcFile
+		if sf, err = NewSrcFileFromFilespec(filespec); err == nil {
+			src = sf
+		}
 	}
-	return NewSrcFile(file)
+	return src, err
 }
 
 // NewSrcFile creates a new SrcFile with the specified os.File.
+//
+// The file is never re-opened, meaning modifications to the file are
+// detected but replacing the file is not. See `NewSrcFileFromFilespec`.
 func NewSrcFile(file *os.File) (*SrcFile, error) {
+	return NewSrcFileWithMode(file, ini.ParseKeepLastGood)
+}
+
+// NewSrcFileWithMode creates a new SrcFile with the specified os.File and
+// `ini.ParseMode`. See `NewSrcFile` and `SetParseMode`.
+func NewSrcFileWithMode(file *os.File, mode ini.ParseMode) (*SrcFile, error) {
+	return newSrcFile(newFileTracker(file), mode)
+}
+
+// newSrcFile creates a SrcFile and loads the file. Parse errors cause
+// an error to be returned unless `mode` is `ini.ParseLenient`.
+func newSrcFile(tracker *fileTracker, mode ini.ParseMode) (*SrcFile, error) {
 	sf := &SrcFile{}
 	sf.freq = time.Minute
-	sf.file = file
-	if err := sf.ini.LoadFromFile(file); err != nil {
-		return nil, err
+	sf.tracker = tracker
+	sf.files = newFileSet(tracker)
+	sf.ini.SetParseMode(mode)
+	if parsed, err := sf.load(); err != nil {
+		if !parsed || mode != ini.ParseLenient {
+			return nil, err
+		}
+		sf.warn = err
 	}
 	return sf, nil
 }
 
+// SetParseMode sets how errors parsing the file are handled when reloading:
+//
+// * `ini.ParseKeepLastGood` returns the error and no properties, meaning
+// `Config` retains the last good properties. This is the default.
+// * `ini.ParseStrict` returns a `*PartialLoadError` and an empty map, meaning
+// the whole file is rejected.
+// * `ini.ParseLenient` returns a `*PartialLoadError` and all properties that
+// parsed successfully.
+//
+// In all modes `Config` reports the error to any `ErrorListener`.
+func (sf *SrcFile) SetParseMode(mode ini.ParseMode) {
+	sf.ini.SetParseMode(mode)
+}
+
+// SetWantStripInlineComments sets the flag determining if comments following
+// a value on the same line are removed. See `ini.Ini.SetWantStripInlineComments`.
+// The file is parsed again on the next call to `GetProps`.
+func (sf *SrcFile) SetWantStripInlineComments(b bool) {
+	sf.ini.SetWantStripInlineComments(b)
+	sf.mutex.Lock()
+	sf.loaded = time.Time{}
+	sf.mutex.Unlock()
+}
+
+// SetWantJoinContinuations sets the flag determining if values may span
+// multiple lines using a trailing backslash or indented continuation lines.
+// See `ini.Ini.SetWantJoinContinuations`. The file is parsed again on the next
+// call to `GetProps`.
+func (sf *SrcFile) SetWantJoinContinuations(b bool) {
+	sf.ini.SetWantJoinContinuations(b)
+	sf.mutex.Lock()
+	sf.loaded = time.Time{}
+	sf.mutex.Unlock()
+}
+
 // GetProps fetches all the properties from a source and returns
-// them as a map.
+// them as a map. See `SetParseMode` for how errors are handled.
 func (sf *SrcFile) GetProps() (map[string]string, error) {
 	lm, err := sf.GetLastModified()
 	if err != nil {
 		return nil, err
 	}
 
+	sf.mutex.Lock()
+	loaded := sf.loaded
+	warn := sf.warn
+	sf.warn = nil
+	sf.mutex.Unlock()
+
 	// Check if we need to reload.
-	if sf.ini.GetLastModified() != lm {
-		if err := sf.ini.LoadFromFile(sf.file); err != nil {
-			return nil, err
+	if loaded != lm {
+		parsed, err := sf.load()
+		if err != nil {
+			if !parsed || sf.ini.GetParseMode() == ini.ParseKeepLastGood {
+				return nil, err
+			}
+			return sf.ini.ToMap(), &PartialLoadError{Err: err}
 		}
+		warn = nil
+	}
+	if warn != nil {
+		return sf.ini.ToMap(), &PartialLoadError{Err: warn}
 	}
 	return sf.ini.ToMap(), nil
 }
 
 // GetLastModified returns the time of the latest modification to any
-// property value within the source.
+// property value within the source, including within any included files.
 func (sf *SrcFile) GetLastModified() (time.Time, error) {
-	fi, err := sf.file.Stat()
+	return sf.files.lastModified()
+}
+
+// load reads and parses the file. `parsed` is false if the file could not
+// be read, otherwise any error returned is a parse error that has been handled
+// according to the `ini.ParseMode`.
+//
+// The files included are checked for changes from then on. Changes are checked
+// before reading, meaning changes made while loading are detected next time.
+func (sf *SrcFile) load() (parsed bool, err error) {
+	lm, err := sf.files.lastModified()
 	if err != nil {
-		return time.Now(), err
+		return false, err
 	}
-	return fi.ModTime(), nil
+	data, _, err := sf.tracker.read()
+	if err != nil {
+		return false, err
+	}
+	err = sf.ini.LoadFromNamedString(string(data), sf.tracker.name())
+	sf.files.setDeps(sf.ini.GetIncludes())
+
+	// Errors are reported once per modification rather than on every call.
+	sf.mutex.Lock()
+	sf.loaded = lm
+	sf.mutex.Unlock()
+	return true, err
+}
+
+// SetChangeDetection sets the strategy used to detect changes to the file
+// and any files it includes. Defaults to `DetectModTime`.
+func (sf *SrcFile) SetChangeDetection(detect ChangeDetection) {
+	sf.files.setDetection(detect)
+}
+
+// Notify starts watching the file, and any files it includes, for changes
+// until `done` is closed. See `SourceNotifier`.
+func (sf *SrcFile) Notify(done <-chan struct{}) (<-chan struct{}, error) {
+	return sf.files.notify(done)
 }
@@ -0,0 +1,300 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wiggin77/cfg/ini"
+)
+
+// checkProp fetches props from a source and checks the value of one prop.
+func checkProp(t *testing.T, src Source, name string, want string) {
+	t.Helper()
+	props, err := src.GetProps()
+	if err != nil {
+		t.Errorf("GetProps; expected err=nil, got err=%v", err)
+		return
+	}
+	if got := props[name]; got != want {
+		t.Errorf("GetProps; expected %s=%s, got %s", name, want, got)
+	}
+}
+
+func TestSrcFile_Modified(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.conf")
+	writeTestFile(t, filespec, "name=one\n")
+
+	file, err := os.Open(filespec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	src, err := NewSrcFile(file)
+	if err != nil {
+		t.Fatalf("NewSrcFile; expected err=nil, got err=%v", err)
+	}
+	checkProp(t, src, "name", "one")
+
+	// modify in place
+	writeTestFile(t, filespec, "name=two\n")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filespec, future, future); err != nil {
+		t.Fatal(err)
+	}
+	checkProp(t, src, "name", "two")
+}
+
+func TestSrcFile_Replaced(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.conf")
+	writeTestFile(t, filespec, "name=one\n")
+
+	src, err := NewSrcFileFromFilespec(filespec)
+	if err != nil {
+		t.Fatalf("NewSrcFileFromFilespec; expected err=nil, got err=%v", err)
+	}
+	checkProp(t, src, "name", "one")
+	lm, _ := src.GetLastModified()
+
+	// rename a new file, with an older mod time, over the original.
+	tmp := filepath.Join(dir, "test.conf.tmp")
+	writeTestFile(t, tmp, "name=two\n")
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(tmp, past, past); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filespec); err != nil {
+		t.Fatal(err)
+	}
+	lm2, _ := src.GetLastModified()
+	if !lm.Before(lm2) {
+		t.Errorf("GetLastModified after replace; expected %v before %v", lm, lm2)
+	}
+	checkProp(t, src, "name", "two")
+
+	// file temporarily missing; props are retained.
+	if err := os.Remove(filespec); err != nil {
+		t.Fatal(err)
+	}
+	lm3, err := src.GetLastModified()
+	if err != nil || !lm3.Equal(lm2) {
+		t.Errorf("GetLastModified for missing file; expected %v, nil; got %v, %v", lm2, lm3, err)
+	}
+	checkProp(t, src, "name", "two")
+
+	writeTestFile(t, filespec, "name=three\n")
+	checkProp(t, src, "name", "three")
+}
+
+func TestSrcFile_SymlinkSwap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// mimic a Kubernetes ConfigMap volume
+	for _, d := range []string{"v1", "v2"} {
+		if err := os.Mkdir(filepath.Join(dir, d), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, filepath.Join(dir, "v1", "test.conf"), "name=one\n")
+	writeTestFile(t, filepath.Join(dir, "v2", "test.conf"), "name=two\n")
+	if err := os.Symlink("v1", filepath.Join(dir, "..data")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	filespec := filepath.Join(dir, "test.conf")
+	if err := os.Symlink(filepath.Join("..data", "test.conf"), filespec); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := NewSrcFileFromFilespec(filespec)
+	if err != nil {
+		t.Fatalf("NewSrcFileFromFilespec; expected err=nil, got err=%v", err)
+	}
+	checkProp(t, src, "name", "one")
+
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink("v2", tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	checkProp(t, src, "name", "two")
+}
+
+func TestSrcFile_ContentHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.conf")
+	writeTestFile(t, filespec, "name=one\n")
+	fi, err := os.Stat(filespec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := NewSrcFileFromFilespec(filespec)
+	if err != nil {
+		t.Fatalf("NewSrcFileFromFilespec; expected err=nil, got err=%v", err)
+	}
+	src.SetChangeDetection(DetectContentHash)
+	lm, _ := src.GetLastModified()
+
+	// touch without changing contents
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filespec, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if lm2, _ := src.GetLastModified(); !lm2.Equal(lm) {
+		t.Errorf("GetLastModified after touch; expected %v, got %v", lm, lm2)
+	}
+
+	// change contents without changing size or mod time
+	writeTestFile(t, filespec, "name=two\n")
+	if err := os.Chtimes(filespec, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if lm3, _ := src.GetLastModified(); !lm.Before(lm3) {
+		t.Errorf("GetLastModified after edit; expected %v before %v", lm, lm3)
+	}
+	checkProp(t, src, "name", "two")
+}
+
+func TestSrcFile_ParseModes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.conf")
+	writeTestFile(t, filespec, "name=one\nbroken\n")
+
+	// the default rejects a broken file
+	if _, err := NewSrcFileFromFilespec(filespec); err == nil {
+		t.Error("NewSrcFileFromFilespec; expected error")
+	}
+
+	// lenient loads what parses and reports the error once
+	src, err := NewSrcFileFromFilespecWithMode(filespec, ini.ParseLenient)
+	if err != nil {
+		t.Fatalf("NewSrcFileFromFilespecWithMode; expected err=nil, got err=%v", err)
+	}
+	props, err := src.GetProps()
+	if err == nil {
+		t.Error("GetProps; expected error")
+	}
+	if props["name"] != "one" {
+		t.Errorf("GetProps; expected name=one, got %s", props["name"])
+	}
+	checkProp(t, src, "name", "one")
+
+	tests := []struct {
+		mode    ini.ParseMode
+		wantNil bool
+		want    string
+	}{
+		{mode: ini.ParseKeepLastGood, wantNil: true},
+		{mode: ini.ParseStrict, want: ""},
+		{mode: ini.ParseLenient, want: "three"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			writeTestFile(t, filespec, "name=two\n")
+			src, err := NewSrcFileFromFilespecWithMode(filespec, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkProp(t, src, "name", "two")
+
+			writeTestFile(t, filespec, "name=three\nbroken\n")
+			future := time.Now().Add(time.Hour)
+			if err := os.Chtimes(filespec, future, future); err != nil {
+				t.Fatal(err)
+			}
+			props, err := src.GetProps()
+			if err == nil {
+				t.Error("GetProps; expected error")
+			}
+			if _, partial := err.(*PartialLoadError); partial == tt.wantNil {
+				t.Errorf("GetProps; expected partial=%t, got %T", !tt.wantNil, err)
+			}
+			if (props == nil) != tt.wantNil {
+				t.Errorf("GetProps; expected nil=%t, got %v", tt.wantNil, props)
+			}
+			if !tt.wantNil && props["name"] != tt.want {
+				t.Errorf("GetProps; expected name=%s, got %s", tt.want, props["name"])
+			}
+		})
+	}
+}
+
+func TestSrcFile_Includes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Mkdir(filepath.Join(dir, "shared"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	filespec := filepath.Join(dir, "app.conf")
+	base := filepath.Join(dir, "shared", "base.conf")
+	writeTestFile(t, filespec, "name=app\n!include shared/base.conf\n")
+	writeTestFile(t, base, "level=one\n")
+	fi, err := os.Stat(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, detect := range []ChangeDetection{DetectModTime, DetectContentHash} {
+		src, err := NewSrcFileFromFilespec(filespec)
+		if err != nil {
+			t.Fatalf("NewSrcFileFromFilespec; expected err=nil, got err=%v", err)
+		}
+		src.SetChangeDetection(detect)
+		checkProp(t, src, "level", "one")
+		lm, _ := src.GetLastModified()
+
+		writeTestFile(t, base, "level=two\n")
+		mt := fi.ModTime().Add(time.Hour) // ensure the change is visible to mod time detection
+		if detect == DetectContentHash {
+			mt = fi.ModTime()
+		}
+		if err := os.Chtimes(base, mt, mt); err != nil {
+			t.Fatal(err)
+		}
+		if lm2, _ := src.GetLastModified(); !lm.Before(lm2) {
+			t.Errorf("GetLastModified after editing include (%d); expected %v before %v", detect, lm, lm2)
+		}
+		checkProp(t, src, "level", "two")
+
+		writeTestFile(t, base, "level=one\n")
+		if err := os.Chtimes(base, fi.ModTime(), fi.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
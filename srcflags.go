@@ -0,0 +1,79 @@
+package cfg
+
+import (
+	"flag"
+	"sync"
+)
+
+// SrcFlags is a configuration `Source` backed by a `flag.FlagSet`.
+//
+// Only flags explicitly set on the command line are included, meaning
+// flag defaults never shadow values from lower priority sources. Flag
+// names are used as property names, e.g. `--db.host=x` sets property
+// `db.host`.
+type SrcFlags struct {
+	mutex sync.RWMutex
+	fs    *flag.FlagSet
+}
+
+// NewSrcFlags creates a `SrcFlags` wrapping the specified `flag.FlagSet`.
+// If `fs` is nil then `flag.CommandLine` is used.
+func NewSrcFlags(fs *flag.FlagSet) *SrcFlags {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	return &SrcFlags{fs: fs}
+}
+
+// Register defines a string flag for the named property, with the
+// specified help text. Flags must be registered before the `flag.FlagSet`
+// is parsed. Names already defined in the `flag.FlagSet` are ignored.
+func (sf *SrcFlags) Register(name string, usage string) {
+	sf.mutex.Lock()
+	defer sf.mutex.Unlock()
+
+	if sf.fs.Lookup(name) == nil {
+		sf.fs.String(name, "", usage)
+	}
+}
+
+// RegisterKeys defines a string flag for each property name in `keys`,
+// which maps property names to help text.
+//
+// See `Register`.
+func (sf *SrcFlags) RegisterKeys(keys map[string]string) {
+	for name, usage := range keys {
+		sf.Register(name, usage)
+	}
+}
+
+// RegisterSource defines a string flag for each property provided by `src`,
+// allowing any property from an existing source, such as an INI file, to be
+// overridden on the command line. `usage` optionally maps property names to
+// help text. An error is returned, and no flags are defined, if the properties
+// cannot be fetched from `src`.
+//
+// See `Register`.
+func (sf *SrcFlags) RegisterSource(src Source, usage map[string]string) error {
+	props, err := src.GetProps()
+	if err != nil {
+		return err
+	}
+	for name := range props {
+		sf.Register(name, usage[name])
+	}
+	return nil
+}
+
+// GetProps fetches all the properties from a source and returns
+// them as a map. Only flags that have been set are included.
+func (sf *SrcFlags) GetProps() (map[string]string, error) {
+	sf.mutex.RLock()
+	defer sf.mutex.RUnlock()
+
+	m := make(map[string]string)
+	sf.fs.Visit(func(f *flag.Flag) {
+		m[f.Name] = f.Value.String()
+	})
+	return m, nil
+}
@@ -0,0 +1,86 @@
+package cfg
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestSrcFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Int("retries", 5, "number of retries")
+
+	src := NewSrcFlags(fs)
+	src.RegisterKeys(map[string]string{
+		"db.host": "database host",
+		"db.port": "database port",
+		"retries": "ignored, already defined",
+	})
+
+	if f := fs.Lookup("db.host"); f == nil || f.Usage != "database host" {
+		t.Errorf("RegisterKeys; expected flag db.host to be defined, got %v", f)
+	}
+
+	if err := fs.Parse([]string{"--db.host=remote", "-retries", "7"}); err != nil {
+		t.Fatalf("Parse; expected err=nil, got err=%v", err)
+	}
+
+	got, err := src.GetProps()
+	if err != nil {
+		t.Errorf("GetProps; expected err=nil, got err=%v", err)
+	}
+	want := map[string]string{"db.host": "remote", "retries": "7"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() = %v, want %v", got, want)
+	}
+
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(NewSrcMapFromMap(map[string]string{"db.host": "localhost", "db.port": "5432"}))
+	config.PrependSource(src)
+
+	if val, _ := config.String("db.host", ""); val != "remote" {
+		t.Errorf("flag should override; expected db.host=remote, got %s", val)
+	}
+	if val, _ := config.String("db.port", ""); val != "5432" {
+		t.Errorf("unset flag should not shadow; expected db.port=5432, got %s", val)
+	}
+}
+
+func TestSrcFlags_RegisterSource(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Int("retries", 5, "number of retries")
+
+	src := NewSrcFlags(fs)
+	base := NewSrcMapFromMap(map[string]string{"db.host": "localhost", "db.port": "5432", "retries": "3"})
+	if err := src.RegisterSource(base, map[string]string{"db.host": "database host"}); err != nil {
+		t.Fatalf("RegisterSource; expected err=nil, got err=%v", err)
+	}
+
+	if f := fs.Lookup("db.host"); f == nil || f.Usage != "database host" {
+		t.Errorf("RegisterSource; expected flag db.host with usage, got %v", f)
+	}
+	if f := fs.Lookup("db.port"); f == nil || f.Usage != "" {
+		t.Errorf("RegisterSource; expected flag db.port without usage, got %v", f)
+	}
+	if f := fs.Lookup("retries"); f == nil || f.Usage != "number of retries" {
+		t.Errorf("RegisterSource; expected existing flag retries unchanged, got %v", f)
+	}
+
+	if err := fs.Parse([]string{"--db.port=6543"}); err != nil {
+		t.Fatalf("Parse; expected err=nil, got err=%v", err)
+	}
+	got, _ := src.GetProps()
+	if want := map[string]string{"db.port": "6543"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() = %v, want %v", got, want)
+	}
+
+	bad := &failingSrc{errGet: errors.New("unavailable")}
+	if err := src.RegisterSource(bad, nil); err == nil {
+		t.Error("RegisterSource; expected error for failing source")
+	}
+}
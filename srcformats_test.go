@@ -0,0 +1,136 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleYAML = `
+name: app
+debug: true
+ratio: 1.85
+created: 2001-12-14T21:59:43.1Z
+db:
+  host: localhost
+  port: 5432
+hosts:
+  - a
+  - b
+  - name: c
+`
+
+const sampleTOML = `
+name = "app"
+debug = true
+ratio = 1.85
+created = 2001-12-14T21:59:43.1Z
+
+[db]
+host = "localhost"
+port = 5432
+
+hosts = ["a", "b"]
+
+[[servers]]
+name = "c"
+`
+
+func TestSrcYAML_FromReader(t *testing.T) {
+	src, err := NewSrcYAMLFromReader(strings.NewReader(sampleYAML))
+	if err != nil {
+		t.Fatalf("NewSrcYAMLFromReader; expected err=nil, got err=%v", err)
+	}
+	got, _ := src.GetProps()
+	want := map[string]string{
+		"name":         "app",
+		"debug":        "true",
+		"ratio":        "1.85",
+		"created":      "2001-12-14T21:59:43.1Z",
+		"db.host":      "localhost",
+		"db.port":      "5432",
+		"hosts.0":      "a",
+		"hosts.1":      "b",
+		"hosts.2.name": "c",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() = %v, want %v", got, want)
+	}
+
+	if src, err := NewSrcYAMLFromReader(strings.NewReader("")); err != nil {
+		t.Errorf("NewSrcYAMLFromReader; expected err=nil for empty doc, got err=%v", err)
+	} else if props, _ := src.GetProps(); len(props) != 0 {
+		t.Errorf("GetProps for empty doc; expected no props, got %v", props)
+	}
+
+	if _, err := NewSrcYAMLFromReader(strings.NewReader("- a\n- b\n")); err == nil {
+		t.Error("NewSrcYAMLFromReader; expected error for sequence")
+	}
+}
+
+func TestSrcTOML_FromReader(t *testing.T) {
+	src, err := NewSrcTOMLFromReader(strings.NewReader(sampleTOML))
+	if err != nil {
+		t.Fatalf("NewSrcTOMLFromReader; expected err=nil, got err=%v", err)
+	}
+	got, _ := src.GetProps()
+	want := map[string]string{
+		"name":           "app",
+		"debug":          "true",
+		"ratio":          "1.85",
+		"created":        "2001-12-14T21:59:43.1Z",
+		"db.host":        "localhost",
+		"db.port":        "5432",
+		"db.hosts.0":     "a",
+		"db.hosts.1":     "b",
+		"servers.0.name": "c",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() = %v, want %v", got, want)
+	}
+
+	if _, err := NewSrcTOMLFromReader(strings.NewReader("name = ")); err == nil {
+		t.Error("NewSrcTOMLFromReader; expected error for invalid TOML")
+	}
+}
+
+func TestNewSrcFromFilespec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"test.json": `{"server": {"port": "8080"}}`,
+		"test.yml":  "server:\n  port: 8080\n",
+		"test.YAML": "server:\n  port: 8080\n",
+		"test.toml": "[server]\nport = 8080\n",
+		"test.ini":  "[server]\nport = 8080\n",
+		"test.conf": "server.port = 8080\n",
+	}
+	for name, data := range files {
+		filespec := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(filespec, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		src, err := NewSrcFromFilespec(filespec)
+		if err != nil {
+			t.Errorf("NewSrcFromFilespec(%s); expected err=nil, got err=%v", name, err)
+			continue
+		}
+		config := &Config{}
+		config.AppendSource(src)
+		if port, err := config.Int("server.port", 0); err != nil || port != 8080 {
+			t.Errorf("NewSrcFromFilespec(%s); expected server.port=8080, got %d, err=%v", name, port, err)
+		}
+		config.Shutdown()
+	}
+
+	if src, err := NewSrcFromFilespec(filepath.Join(dir, "missing.json")); err == nil || src != nil {
+		t.Errorf("NewSrcFromFilespec for missing file; expected nil src and error, got %v, %v", src, err)
+	}
+}
@@ -0,0 +1,71 @@
+package cfg
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// SrcJSON is a configuration `Source` backed by a file or reader containing
+// a JSON object. Nested objects are flattened into dotted property names the
+// same way INI sections are, e.g. `{"db":{"host":"x"}}` becomes `db.host=x`.
+// Array elements are named using their zero-based index, e.g.
+// `{"hosts":["a","b"]}` becomes `hosts.0=a` and `hosts.1=b`.
+type SrcJSON struct {
+	srcDecoded
+}
+
+// NewSrcJSONFromFilespec creates a new SrcJSON with the specified filespec.
+// The file is re-opened by path whenever it changes, the same as
+// `NewSrcFileFromFilespec`.
+func NewSrcJSONFromFilespec(filespec string) (*SrcJSON, error) {
+	sj := newSrcJSON()
+	if err := sj.loadFromFile(newFileTrackerFromFilespec(filespec)); err != nil {
+		return nil, err
+	}
+	return sj, nil
+}
+
+// NewSrcJSON creates a new SrcJSON with the specified os.File.
+func NewSrcJSON(file *os.File) (*SrcJSON, error) {
+	sj := newSrcJSON()
+	if err := sj.loadFromFile(newFileTracker(file)); err != nil {
+		return nil, err
+	}
+	return sj, nil
+}
+
+// NewSrcJSONFromReader creates a new SrcJSON with the contents of
+// an `io.Reader`. The source is never reloaded.
+func NewSrcJSONFromReader(reader io.Reader) (*SrcJSON, error) {
+	sj := newSrcJSON()
+	if err := sj.loadFromReader(reader); err != nil {
+		return nil, err
+	}
+	return sj, nil
+}
+
+func newSrcJSON() *SrcJSON {
+	sj := &SrcJSON{}
+	sj.freq = time.Minute
+	sj.decode = decodeJSON
+	return sj
+}
+
+// decodeJSON decodes a JSON object, preserving the exact text of numbers.
+func decodeJSON(reader io.Reader) (map[string]interface{}, error) {
+	dec := json.NewDecoder(reader)
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("JSON must contain an object")
+	}
+	return m, nil
+}
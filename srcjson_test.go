@@ -0,0 +1,92 @@
+package cfg
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleJSON = `{
+	"name": "app",
+	"debug": true,
+	"ratio": 1.85,
+	"big": 12345678901234567890,
+	"none": null,
+	"db": {"host": "localhost", "port": 5432, "opts": {}},
+	"hosts": ["a", "b", {"name": "c"}],
+	"empty": []
+}`
+
+func TestSrcJSON_FromReader(t *testing.T) {
+	src, err := NewSrcJSONFromReader(strings.NewReader(sampleJSON))
+	if err != nil {
+		t.Fatalf("NewSrcJSONFromReader; expected err=nil, got err=%v", err)
+	}
+	got, err := src.GetProps()
+	if err != nil {
+		t.Errorf("GetProps; expected err=nil, got err=%v", err)
+	}
+	want := map[string]string{
+		"name":         "app",
+		"debug":        "true",
+		"ratio":        "1.85",
+		"big":          "12345678901234567890",
+		"none":         "",
+		"db.host":      "localhost",
+		"db.port":      "5432",
+		"hosts.0":      "a",
+		"hosts.1":      "b",
+		"hosts.2.name": "c",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProps() = %v, want %v", got, want)
+	}
+
+	if lm, _ := src.GetLastModified(); !lm.IsZero() {
+		t.Errorf("GetLastModified for reader; expected zero time, got %v", lm)
+	}
+
+	if _, err := NewSrcJSONFromReader(strings.NewReader(`["not", "an", "object"]`)); err == nil {
+		t.Error("NewSrcJSONFromReader; expected error for array")
+	}
+	if _, err := NewSrcJSONFromReader(strings.NewReader(`{"bad":`)); err == nil {
+		t.Error("NewSrcJSONFromReader; expected error for invalid JSON")
+	}
+}
+
+func TestSrcJSON_Reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfgtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filespec := filepath.Join(dir, "test.json")
+	if err := ioutil.WriteFile(filespec, []byte(`{"db": {"host": "one"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := NewSrcJSONFromFilespec(filespec)
+	if err != nil {
+		t.Fatalf("NewSrcJSONFromFilespec; expected err=nil, got err=%v", err)
+	}
+	if props, _ := src.GetProps(); props["db.host"] != "one" {
+		t.Errorf("GetProps; expected db.host=one, got %v", props)
+	}
+
+	if err := ioutil.WriteFile(filespec, []byte(`{"db": {"host": "two"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filespec, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	if props, _ := src.GetProps(); props["db.host"] != "two" {
+		t.Errorf("GetProps after modification; expected db.host=two, got %v", props)
+	}
+}
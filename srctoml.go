@@ -0,0 +1,62 @@
+package cfg
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SrcTOML is a configuration `Source` backed by a file or reader containing
+// TOML. Tables and arrays are flattened into dotted property names the same
+// way as `SrcJSON`.
+type SrcTOML struct {
+	srcDecoded
+}
+
+// NewSrcTOMLFromFilespec creates a new SrcTOML with the specified filespec.
+// The file is re-opened by path whenever it changes, the same as
+// `NewSrcFileFromFilespec`.
+func NewSrcTOMLFromFilespec(filespec string) (*SrcTOML, error) {
+	st := newSrcTOML()
+	if err := st.loadFromFile(newFileTrackerFromFilespec(filespec)); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// NewSrcTOML creates a new SrcTOML with the specified os.File.
+func NewSrcTOML(file *os.File) (*SrcTOML, error) {
+	st := newSrcTOML()
+	if err := st.loadFromFile(newFileTracker(file)); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// NewSrcTOMLFromReader creates a new SrcTOML with the contents of
+// an `io.Reader`. The source is never reloaded.
+func NewSrcTOMLFromReader(reader io.Reader) (*SrcTOML, error) {
+	st := newSrcTOML()
+	if err := st.loadFromReader(reader); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func newSrcTOML() *SrcTOML {
+	st := &SrcTOML{}
+	st.freq = time.Minute
+	st.decode = decodeTOML
+	return st
+}
+
+// decodeTOML decodes a TOML document.
+func decodeTOML(reader io.Reader) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if _, err := toml.NewDecoder(reader).Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,63 @@
+package cfg
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SrcYAML is a configuration `Source` backed by a file or reader containing
+// a YAML mapping. Nested mappings and sequences are flattened into dotted
+// property names the same way as `SrcJSON`.
+type SrcYAML struct {
+	srcDecoded
+}
+
+// NewSrcYAMLFromFilespec creates a new SrcYAML with the specified filespec.
+// The file is re-opened by path whenever it changes, the same as
+// `NewSrcFileFromFilespec`.
+func NewSrcYAMLFromFilespec(filespec string) (*SrcYAML, error) {
+	sy := newSrcYAML()
+	if err := sy.loadFromFile(newFileTrackerFromFilespec(filespec)); err != nil {
+		return nil, err
+	}
+	return sy, nil
+}
+
+// NewSrcYAML creates a new SrcYAML with the specified os.File.
+func NewSrcYAML(file *os.File) (*SrcYAML, error) {
+	sy := newSrcYAML()
+	if err := sy.loadFromFile(newFileTracker(file)); err != nil {
+		return nil, err
+	}
+	return sy, nil
+}
+
+// NewSrcYAMLFromReader creates a new SrcYAML with the contents of
+// an `io.Reader`. The source is never reloaded.
+func NewSrcYAMLFromReader(reader io.Reader) (*SrcYAML, error) {
+	sy := newSrcYAML()
+	if err := sy.loadFromReader(reader); err != nil {
+		return nil, err
+	}
+	return sy, nil
+}
+
+func newSrcYAML() *SrcYAML {
+	sy := &SrcYAML{}
+	sy.freq = time.Minute
+	sy.decode = decodeYAML
+	return sy
+}
+
+// decodeYAML decodes a YAML mapping. An empty document results in an
+// empty map.
+func decodeYAML(reader io.Reader) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if err := yaml.NewDecoder(reader).Decode(&m); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,60 @@
+package cfg
+
+import (
+	"strings"
+)
+
+// WatchFunc is called with the details of a change to a watched property.
+type WatchFunc func(chg PropChange)
+
+// watcher is a `ChangedPropsListener` that filters changes by property
+// name or prefix before calling a `WatchFunc`.
+type watcher struct {
+	name   string
+	prefix bool
+	fn     WatchFunc
+}
+
+// Watch registers a function to be called whenever the effective value of
+// the named property changes.
+//
+// The returned listener can be passed to `RemoveChangedListener` to stop
+// watching.
+func (config *Config) Watch(name string, fn WatchFunc) ChangedListener {
+	w := &watcher{name: name, fn: fn}
+	config.AddChangedListener(w)
+	return w
+}
+
+// WatchPrefix registers a function to be called whenever the effective value
+// of any property whose name starts with `prefix` changes. The function is
+// called once per changed property.
+//
+// The returned listener can be passed to `RemoveChangedListener` to stop
+// watching.
+func (config *Config) WatchPrefix(prefix string, fn WatchFunc) ChangedListener {
+	w := &watcher{name: prefix, prefix: true, fn: fn}
+	config.AddChangedListener(w)
+	return w
+}
+
+// ConfigChanged is never called since `watcher` implements `ChangedPropsListener`.
+func (w *watcher) ConfigChanged(cfg *Config, src SourceMonitored) {
+}
+
+// PropsChanged calls the `WatchFunc` for each matching change.
+func (w *watcher) PropsChanged(cfg *Config, src Source, changes []PropChange) {
+	for _, chg := range changes {
+		if w.matches(chg.Name) {
+			w.fn(chg)
+		}
+	}
+}
+
+// matches returns true if the property name is being watched.
+func (w *watcher) matches(name string) bool {
+	if w.prefix {
+		return strings.HasPrefix(name, w.name)
+	}
+	return name == w.name
+}
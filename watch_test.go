@@ -0,0 +1,47 @@
+package cfg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfig_Watch(t *testing.T) {
+	config := &Config{}
+	defer config.Shutdown()
+	config.AppendSource(NewSrcMapFromMap(map[string]string{"db.host": "localhost", "db.port": "5432", "name": "app"}))
+
+	var gotKey []PropChange
+	var gotPrefix []PropChange
+	wKey := config.Watch("db.host", func(chg PropChange) { gotKey = append(gotKey, chg) })
+	wPrefix := config.WatchPrefix("db.", func(chg PropChange) { gotPrefix = append(gotPrefix, chg) })
+
+	config.PrependSource(NewSrcMapFromMap(map[string]string{"db.host": "remote", "db.user": "admin", "name": "other"}))
+
+	wantKey := []PropChange{
+		{Name: "db.host", Type: PropModified, OldVal: "localhost", NewVal: "remote"},
+	}
+	if !reflect.DeepEqual(gotKey, wantKey) {
+		t.Errorf("Watch got %v; expected %v", gotKey, wantKey)
+	}
+
+	wantPrefix := []PropChange{
+		{Name: "db.host", Type: PropModified, OldVal: "localhost", NewVal: "remote"},
+		{Name: "db.user", Type: PropAdded, NewVal: "admin"},
+	}
+	if !reflect.DeepEqual(gotPrefix, wantPrefix) {
+		t.Errorf("WatchPrefix got %v; expected %v", gotPrefix, wantPrefix)
+	}
+
+	if err := config.RemoveChangedListener(wKey); err != nil {
+		t.Errorf("RemoveChangedListener for Watch; got err=%v", err)
+	}
+	if err := config.RemoveChangedListener(wPrefix); err != nil {
+		t.Errorf("RemoveChangedListener for WatchPrefix; got err=%v", err)
+	}
+
+	gotKey, gotPrefix = nil, nil
+	config.PrependSource(NewSrcMapFromMap(map[string]string{"db.host": "other"}))
+	if len(gotKey) != 0 || len(gotPrefix) != 0 {
+		t.Errorf("watch func called after removal; got %v, %v", gotKey, gotPrefix)
+	}
+}